@@ -12,6 +12,7 @@ import (
 func Render(w io.Writer, changes []diff.Change) {
 	var curFG, curBG node.Color
 	var curStyle node.StyleFlags
+	var curLink string
 	first := true
 
 	for _, ch := range changes {
@@ -26,16 +27,29 @@ func Render(w io.Writer, changes []diff.Change) {
 				curStyle = c.Style
 				first = false
 			}
+			if c.Link != curLink {
+				writeLink(w, c.Link)
+				curLink = c.Link
+			}
 			fmt.Fprintf(w, "%c", c.Rune)
 		}
 	}
 
 	// Reset at end
+	if curLink != "" {
+		writeLink(w, "")
+	}
 	if !first {
 		fmt.Fprint(w, "\x1b[0m")
 	}
 }
 
+// writeLink opens an OSC 8 hyperlink to url, or closes the open one when url
+// is empty.
+func writeLink(w io.Writer, url string) {
+	fmt.Fprintf(w, "\x1b]8;;%s\x1b\\", url)
+}
+
 func writeSGR(w io.Writer, fg, bg node.Color, style node.StyleFlags) {
 	fmt.Fprint(w, "\x1b[0")
 	if style&node.Bold != 0 {
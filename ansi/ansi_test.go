@@ -60,3 +60,40 @@ func TestRenderNoChanges(t *testing.T) {
 		t.Fatalf("expected empty output, got %q", buf.String())
 	}
 }
+
+func TestRenderHyperlink(t *testing.T) {
+	changes := []diff.Change{
+		{X: 0, Y: 0, Cells: []cell.Cell{
+			{Rune: 'a', Link: "http://x"},
+			{Rune: 'b', Link: "http://x"},
+			{Rune: 'c'},
+		}},
+	}
+	var buf bytes.Buffer
+	Render(&buf, changes)
+	out := buf.String()
+	if strings.Count(out, "\x1b]8;;http://x\x1b\\") != 1 {
+		t.Fatalf("expected one link open, got %q", out)
+	}
+	if !strings.Contains(out, "ab\x1b]8;;\x1b\\c") {
+		t.Fatalf("expected link closed before 'c', got %q", out)
+	}
+}
+
+func TestDetectColorProfile(t *testing.T) {
+	cases := []struct {
+		env  map[string]string
+		want ColorProfile
+	}{
+		{map[string]string{"TERM": "xterm-256color"}, ANSI256},
+		{map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor"}, TrueColor},
+		{map[string]string{"TERM": "xterm-256color", "NO_COLOR": "1"}, NoColor},
+		{map[string]string{"TERM": "vt100"}, ANSI16},
+		{map[string]string{"TERM": "dumb"}, NoColor},
+	}
+	for _, c := range cases {
+		if got := detectColorProfile(func(k string) string { return c.env[k] }); got != c.want {
+			t.Errorf("%v: expected %v, got %v", c.env, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package ansi
+
+import (
+	"os"
+	"strings"
+)
+
+// ColorProfile is the range of colors a terminal can display.
+type ColorProfile int
+
+const (
+	NoColor   ColorProfile = iota // monochrome, or NO_COLOR is set
+	ANSI16                        // the basic 16 colors
+	ANSI256                       // the 256-color palette
+	TrueColor                     // 24-bit color
+)
+
+// String returns the profile's name.
+func (p ColorProfile) String() string {
+	switch p {
+	case ANSI16:
+		return "ansi16"
+	case ANSI256:
+		return "ansi256"
+	case TrueColor:
+		return "truecolor"
+	}
+	return "nocolor"
+}
+
+// DetectColorProfile guesses the terminal's color support from NO_COLOR,
+// COLORTERM and TERM.
+func DetectColorProfile() ColorProfile {
+	return detectColorProfile(os.Getenv)
+}
+
+func detectColorProfile(getenv func(string) string) ColorProfile {
+	if getenv("NO_COLOR") != "" {
+		return NoColor
+	}
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return TrueColor
+	}
+	term := strings.ToLower(getenv("TERM"))
+	switch {
+	case term == "" || term == "dumb":
+		return NoColor
+	case strings.Contains(term, "truecolor") || strings.Contains(term, "direct"):
+		return TrueColor
+	case strings.Contains(term, "256color"):
+		return ANSI256
+	}
+	return ANSI16
+}
@@ -2,8 +2,15 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/stukennedy/tooey/ansi"
@@ -38,6 +45,14 @@ type ScrollMsg struct {
 	Delta int
 }
 
+// ScrollMetricsMsg reports the scroll metrics of a keyed scrollable node
+// after a frame in which they changed, e.g. because content grew or the
+// terminal was resized. Offsets in Metrics are already clamped.
+type ScrollMetricsMsg struct {
+	Key     string
+	Metrics layout.ScrollMetrics
+}
+
 // Cmd is a function that runs asynchronously and returns a Msg.
 type Cmd func() Msg
 
@@ -46,10 +61,14 @@ type Cmd func() Msg
 type Sub func(send func(Msg)) Msg
 
 // UpdateResult is returned from Update: new model + optional async commands.
+// Set Quit, or Err, to quit after starting Cmds; Run waits for them and
+// returns Err.
 type UpdateResult struct {
 	Model interface{}
 	Cmds  []Cmd
 	Subs  []Sub
+	Quit  bool
+	Err   error
 }
 
 // NoCmd returns an UpdateResult with no commands.
@@ -73,21 +92,75 @@ type App struct {
 	Init func() interface{}
 
 	// Update processes a message and returns the new model + optional commands.
-	// Return UpdateResult with nil Model to quit.
+	// Return UpdateResult with Quit set, or a nil Model, to quit.
 	Update func(model interface{}, msg Msg) UpdateResult
 
+	// Middleware wraps Update, the first entry outermost; see Middleware.
+	Middleware []Middleware
+
 	// View renders the model to a node tree.
 	View func(model interface{}, focused string) node.Node
 
+	// Subscriptions declares the subscriptions the model needs. It is called
+	// after every update; new keys are started and missing ones cancelled.
+	Subscriptions func(model interface{}) []Subscription
+
+	// ViewCtx renders the model with the frame's ViewContext. When set it is
+	// used instead of View.
+	ViewCtx func(model interface{}, ctx ViewContext) node.Node
+
+	// Theme is passed to ViewCtx (defaults to DefaultTheme()).
+	Theme Theme
+
+	// OnQuit is called with the final model once in-flight commands have
+	// finished or timed out. Its error is returned from Run.
+	OnQuit func(model interface{}) error
+
+	// ShutdownTimeout bounds how long Run waits for in-flight commands on
+	// quit (defaults to DefaultShutdownTimeout).
+	ShutdownTimeout time.Duration
+
+	// MaxConcurrentCmds caps how many Cmds run at once; the rest are queued
+	// and started in order as others finish. Zero means no limit.
+	MaxConcurrentCmds int
+
 	// Output writer (defaults to os.Stdout).
 	Output io.Writer
 
 	// Input reader (defaults to os.Stdin).
 	Input io.Reader
+
+	// Options configures Ctrl+C, Ctrl+Z and the focus keys.
+	Options Options
+
+	mu  sync.Mutex
+	box *inbox
+
+	// Test seams: the clock that paces frames, and the key source
+	clock    func() time.Time
+	readKeys func(context.Context, io.Reader) <-chan input.Key
 }
 
-// Run starts the application main loop.
+// Run starts the application main loop. It puts the terminal in raw mode
+// when the input is one and restores it on every exit, including panics
+// and SIGINT/SIGTERM/SIGHUP. It returns when the app quits, after
+// cancelling CmdContext commands and waiting for in-flight commands, with
+// the quit error, OnQuit's error or ErrShutdownTimeout.
 func (a *App) Run(ctx context.Context) error {
+	sent, err := a.begin()
+	if err != nil {
+		return err
+	}
+	return a.run(ctx, sent)
+}
+
+// run is Run's main loop; sent is the inbox of this run.
+func (a *App) run(ctx context.Context, sent *inbox) (err error) {
+	defer func() {
+		sent.err = err
+		close(sent.done)
+	}()
+
 	out := a.Output
 	if out == nil {
 		out = os.Stdout
@@ -101,170 +174,385 @@ func (a *App) Run(ctx context.Context) error {
 	defer cancel()
 
 	// Terminal setup
-	ansi.EnterAltScreen(out)
-	ansi.HideCursor(out)
-	ansi.EnableFocusReporting(out)
-	ansi.EnableMouseReporting(out)
-	ansi.ClearScreen(out)
+	tty := newTerminal(in, out)
+	if err := tty.setup(); err != nil {
+		return err
+	}
+	defer tty.release()
 	defer func() {
-		ansi.DisableMouseReporting(out)
-		ansi.DisableFocusReporting(out)
-		ansi.ShowCursor(out)
-		ansi.LeaveAltScreen(out)
+		if r := recover(); r != nil {
+			tty.release()
+			err = report(r, debug.Stack())
+		}
 	}()
 
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
 	// Get terminal size
 	width, height := input.TermSize()
 
+	colors := ansi.DetectColorProfile()
+	theme := a.Theme
+	if theme == (Theme{}) {
+		theme = DefaultTheme()
+	}
+
 	model := a.Init()
+	update := a.update()
 	fm := focus.NewManager()
+	engine := layout.NewEngine()
 
 	var prevBuf *cell.Buffer
+	var prevMetrics map[string]layout.ScrollMetrics
 
 	// Message channels
-	keyCh := input.ReadKeys(ctx, in)
+	keys := newKeyReader(ctx, in, a.readKeys)
+	defer keys.stop()
 	resizeCh := input.WatchResize(ctx)
 	cmdCh := make(chan Msg, 64)
+	cmds := newCommands(ctx, cmdCh, a.MaxConcurrentCmds)
 
-	// Frame rate limiter
-	frameTicker := time.NewTicker(33 * time.Millisecond) // ~30 FPS
-	defer frameTicker.Stop()
+	var subs subscriptions
+	defer subs.stop()
 
-	needsRender := true
+	quit := func(err error) error {
+		subs.stop()
+		timeout := a.ShutdownTimeout
+		if timeout <= 0 {
+			timeout = DefaultShutdownTimeout
+		}
+		finished := cmds.shutdown(timeout)
+		if a.OnQuit != nil {
+			if qerr := a.OnQuit(model); err == nil {
+				err = qerr
+			}
+		}
+		if err == nil && !finished {
+			err = ErrShutdownTimeout
+		}
+		return err
+	}
+	if a.Subscriptions != nil {
+		subs.sync(ctx, a.Subscriptions(model), cmdCh)
+	}
+
+	// Frames are paced by a timer that only runs while a frame is pending,
+	// so an idle app does no work
+	interval := a.Options.frameInterval()
+	frame := time.NewTimer(time.Hour)
+	frame.Stop()
+	defer frame.Stop()
+	clock := a.clock
+	if clock == nil {
+		clock = time.Now
+	}
+	var lastFrame time.Time
+	framePending := false
+	dirty := true
+	var stats frameStats
+
+	var dev *devtools
+	if a.Options.DevTools {
+		dev = newDevtools(a.Options)
+	}
+
+	// Timers started by Tick/Every share one time.Timer
+	var sched timers
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	// Input messages are handled before background ones (commands, timers,
+	// Send); a flood of background messages is spread over several frames
 	msgs := make([]Msg, 0, 16)
+	var backlog []Msg
+	var lastInterrupt time.Time // last Ctrl+C awaiting confirmation
+	ready := make(chan struct{})
+	close(ready)
 
 	for {
-		// Collect messages
+		// Command results and sent messages are only read once the
+		// backlog is empty, so a fast producer blocks instead of queueing
+		// without limit
+		cmdIn, sentIn := cmdCh, sent.msgs
+		if len(backlog) > 0 {
+			cmdIn, sentIn = nil, nil
+		}
+
+		// Wait for something to do. With messages queued or a frame due
+		// the ready case makes this a poll, so shutdown, signals, resizes
+		// and timers are still seen on every pass.
+		var busy chan struct{}
+		if len(msgs) > 0 || len(backlog) > 0 || (dirty && clock().Sub(lastFrame) >= interval) {
+			busy = ready
+		}
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
-		case k, ok := <-keyCh:
+			return quit(ctx.Err())
+		case sig := <-sigCh:
+			return quit(fmt.Errorf("%w: %v", ErrSignal, sig))
+		case k, ok := <-keys.keys:
 			if !ok {
-				return nil
-			}
-			if k.Type == input.CtrlC {
-				return nil
-			}
-			switch k.Type {
-			case input.FocusIn:
-				msgs = append(msgs, FocusMsg{Focused: true})
-			case input.FocusOut:
-				msgs = append(msgs, FocusMsg{Focused: false})
-			case input.MouseScrollUp:
-				msgs = append(msgs, ScrollMsg{Delta: 3})
-			case input.MouseScrollDown:
-				msgs = append(msgs, ScrollMsg{Delta: -3})
-			default:
-				msgs = append(msgs, KeyMsg{Key: k})
+				return quit(nil)
 			}
-			needsRender = true
+			msgs = append(msgs, a.Options.keyMsg(k, &lastInterrupt))
 		case r, ok := <-resizeCh:
 			if !ok {
+				resizeCh = nil
 				continue
 			}
 			width, height = r.Width, r.Height
 			prevBuf = nil // force full redraw
 			ansi.ClearScreen(out) // clear stale content when terminal size changes
 			msgs = append(msgs, ResizeMsg{Width: width, Height: height})
-			needsRender = true
-		case cmdMsg := <-cmdCh:
-			msgs = append(msgs, cmdMsg)
-			needsRender = true
-		case <-frameTicker.C:
-			// Process batched messages
+		case cmdMsg := <-cmdIn:
+			backlog = append(backlog, cmdMsg)
+		case msg := <-sentIn:
+			backlog = append(backlog, msg)
+		case now := <-timer.C:
+			backlog = append(backlog, sched.due(now)...)
+		case <-frame.C:
+			framePending = false
+		case <-busy:
 		}
 
-		// Drain any additional pending messages
-		draining := true
-		for draining {
+		// Drain any additional pending messages, keys first
+	drain:
+		for drained := 0; drained < maxDrain; drained++ {
 			select {
-			case k, ok := <-keyCh:
+			case k, ok := <-keys.keys:
 				if !ok {
-					draining = false
-					continue
-				}
-				if k.Type == input.CtrlC {
-					return nil
+					break drain
 				}
-				switch k.Type {
-				case input.FocusIn:
-					msgs = append(msgs, FocusMsg{Focused: true})
-				case input.FocusOut:
-					msgs = append(msgs, FocusMsg{Focused: false})
-				default:
-					msgs = append(msgs, KeyMsg{Key: k})
+				msgs = append(msgs, a.Options.keyMsg(k, &lastInterrupt))
+				continue
+			default:
+			}
+			select {
+			case k, ok := <-keys.keys:
+				if !ok {
+					break drain
 				}
-				needsRender = true
-			case cmdMsg := <-cmdCh:
-				msgs = append(msgs, cmdMsg)
-				needsRender = true
+				msgs = append(msgs, a.Options.keyMsg(k, &lastInterrupt))
+			case cmdMsg := <-cmdIn:
+				backlog = append(backlog, cmdMsg)
+			case msg := <-sentIn:
+				backlog = append(backlog, msg)
 			default:
-				draining = false
+				break drain
 			}
 		}
 
-		if !needsRender {
-			continue
+		// Frame ticks are delivered with the frame they were requested for
+		if clock().Sub(lastFrame) >= interval {
+			backlog = append(backlog, sched.frame(time.Now())...)
+		}
+		if len(msgs) > 0 || len(backlog) > 0 {
+			dirty = true
 		}
 
-		// Handle focus keys before update
-		for _, msg := range msgs {
+		// Input first, then as much of the backlog as fits in a frame
+		msgs = coalesce(msgs)
+		if dev != nil {
+			msgs = dev.keys(msgs)
+		}
+		inputs := len(msgs)
+		msgs = append(msgs, coalesce(backlog)...)
+		backlog = backlog[:0]
+		start := clock()
+
+		// Process all messages through update
+		for i := 0; i < len(msgs); i++ {
+			// At least one background message is handled per pass
+			if i > inputs && clock().Sub(start) > interval {
+				backlog = append(backlog, msgs[i:]...)
+				break
+			}
+			msg := msgs[i]
+			switch m := msg.(type) {
+			case *sequenceStep:
+				if r, ok := m.msg.(*timerRequest); ok && len(m.rest) > 0 {
+					// The rest of the sequence waits for the timer
+					msgs = slices.Insert(msgs, i+1, Msg(r.then(m.rest)))
+					continue
+				}
+				msgs = slices.Insert(msgs, i+1, m.msg, Msg(sequenceNext{rest: m.rest}))
+				continue
+			case sequenceNext:
+				if len(m.rest) > 0 {
+					cmds.run(Sequence(m.rest...))
+				}
+				continue
+			case *timerRequest:
+				sched.add(m, time.Now())
+				continue
+			case cancelTimer:
+				sched.cancel(m.key)
+				continue
+			case QuitMsg:
+				return quit(m.Err)
+			case *panicMsg:
+				panic(m)
+			case suspendMsg:
+				keys.pause()
+				tty.release()
+				suspend()
+				if err := tty.setup(); err != nil {
+					return quit(err)
+				}
+				keys.resume()
+				width, height = input.TermSize()
+				prevBuf = nil
+				msgs = append(msgs, ResizeMsg{Width: width, Height: height}, ResumeMsg{})
+				continue
+			case *execRequest:
+				keys.pause()
+				tty.release()
+				err := runExec(m, in, out)
+				select {
+				case sig := <-sigCh:
+					// A Ctrl+C typed into the program reached the app too
+					if sig != syscall.SIGINT {
+						return quit(fmt.Errorf("%w: %v", ErrSignal, sig))
+					}
+				default:
+				}
+				if err := tty.setup(); err != nil {
+					return quit(err)
+				}
+				keys.resume()
+				width, height = input.TermSize()
+				prevBuf = nil
+				msgs = append(msgs, ResizeMsg{Width: width, Height: height})
+				if m.onExit != nil {
+					if msg := m.onExit(err); msg != nil {
+						msgs = append(msgs, msg)
+					}
+				}
+				continue
+			}
+			// Focus keys move focus, then still reach Update
 			if km, ok := msg.(KeyMsg); ok {
-				switch km.Key.Type {
-				case input.Tab:
+				switch a.Options.focusAction(km.Key) {
+				case focusNext:
 					fm.Next()
-				case input.ShiftTab:
+				case focusPrev:
 					fm.Prev()
-				case input.Escape:
+				case focusPop:
 					fm.PopContext()
 				}
 			}
-		}
-
-		// Process all messages through update
-		for _, msg := range msgs {
-			result := a.Update(model, msg)
-			model = result.Model
-			if model == nil {
-				return nil
+			if dev != nil {
+				dev.record(msg, time.Now())
+			}
+			t := time.Now()
+			result := update(model, msg)
+			stats.Update += time.Since(t)
+			if result.Model != nil {
+				model = result.Model
 			}
 			// Launch async commands
 			for _, cmd := range result.Cmds {
-				c := cmd
-				go func() {
-					if m := c(); m != nil {
-						cmdCh <- m
-					}
-				}()
+				cmds.run(cmd)
 			}
 			// Launch subscriptions
 			for _, sub := range result.Subs {
-				s := sub
-				go func() {
-					if m := s(func(msg Msg) { cmdCh <- msg }); m != nil {
-						cmdCh <- m
-					}
-				}()
+				cmds.sub(sub)
+			}
+			if result.Model == nil || result.Quit || result.Err != nil {
+				return quit(result.Err)
 			}
 		}
 		msgs = msgs[:0]
+		sched.reset(timer, time.Now())
+		if a.Subscriptions != nil {
+			subs.sync(ctx, a.Subscriptions(model), cmdCh)
+		}
+
+		// Render when something changed, at most MaxFPS times a second. The
+		// first change after an idle spell renders at once; later ones wait
+		// for the frame timer, which only runs while a frame is pending.
+		now := clock()
+		if !dirty {
+			if sched.hasFrames() && !framePending {
+				frame.Reset(max(lastFrame.Add(interval).Sub(now), 0))
+				framePending = true
+			}
+			continue
+		}
+		if wait := lastFrame.Add(interval).Sub(now); wait > 0 {
+			if !framePending {
+				frame.Reset(wait)
+				framePending = true
+			}
+			continue
+		}
+		lastFrame = now
+		dirty = false
 
 		// Render pipeline
-		tree := a.View(model, fm.Current())
-		lt := layout.Layout(tree, width, height)
+		t := time.Now()
+		tree := a.view(model, ViewContext{
+			Width:   width,
+			Height:  height,
+			Colors:  colors,
+			Focused: fm.Current(),
+			Time:    time.Now(),
+			Theme:   theme,
+		})
+		stats.View = time.Since(t)
+		t = time.Now()
+		lt := engine.Layout(tree, width, height)
 		fm.Update(lt)
+		stats.Layout = time.Since(t)
 
+		t = time.Now()
 		buf := cell.NewBuffer(width, height)
 		cell.Paint(buf, lt)
+		if dev != nil {
+			dev.paint(buf, lt, fm, theme)
+		}
+		stats.Paint = time.Since(t)
 
 		if prevBuf == nil {
 			prevBuf = cell.NewBuffer(width, height) // empty for first frame
 		}
 
+		t = time.Now()
 		changes := diff.Diff(prevBuf, buf)
-		ansi.Render(out, changes)
+		stats.Diff = time.Since(t)
+		t = time.Now()
+		cw := countingWriter{w: out}
+		ansi.Render(&cw, changes)
+		stats.Render, stats.Bytes = time.Since(t), cw.n
+		if dev != nil {
+			dev.frame(stats, now)
+		}
+		stats = frameStats{}
 
 		prevBuf = buf
-		needsRender = false
+
+		// Report scroll metrics that changed; they are delivered with the
+		// next batch of messages
+		metrics := layout.ScrollMetricsByKey(lt)
+		metricKeys := make([]string, 0, len(metrics))
+		for key := range metrics {
+			metricKeys = append(metricKeys, key)
+		}
+		sort.Strings(metricKeys)
+		for _, key := range metricKeys {
+			if prev, ok := prevMetrics[key]; !ok || prev != metrics[key] {
+				backlog = append(backlog, ScrollMetricsMsg{Key: key, Metrics: metrics[key]})
+			}
+		}
+		prevMetrics = metrics
+
+		// Frame ticks requested in this pass fire with the next frame
+		if sched.hasFrames() && !framePending {
+			frame.Reset(interval)
+			framePending = true
+		}
 	}
 }
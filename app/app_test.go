@@ -1,6 +1,12 @@
 package app
 
-import "testing"
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stukennedy/tooey/node"
+)
 
 func TestWithSub(t *testing.T) {
 	sub := func(send func(Msg)) Msg {
@@ -42,3 +48,89 @@ func TestWithCmd(t *testing.T) {
 		t.Fatalf("expected 1 cmd, got %d", len(r.Cmds))
 	}
 }
+
+func TestViewCtxPreferredOverView(t *testing.T) {
+	a := &App{
+		View: func(m interface{}, focused string) node.Node { return node.Text("view:" + focused) },
+	}
+	ctx := ViewContext{Width: 80, Height: 24, Focused: "input"}
+	if got := a.view(nil, ctx).Props.Text; got != "view:input" {
+		t.Fatalf("expected View to get the focused key, got %q", got)
+	}
+	a.ViewCtx = func(m interface{}, ctx ViewContext) node.Node {
+		return node.Text(fmt.Sprintf("ctx:%dx%d:%s", ctx.Width, ctx.Height, ctx.Focused))
+	}
+	if got := a.view(nil, ctx).Props.Text; got != "ctx:80x24:input" {
+		t.Fatalf("expected ViewCtx to be used, got %q", got)
+	}
+}
+
+type counter struct{ n int }
+
+func TestProgramAdapter(t *testing.T) {
+	p := &Program[counter]{
+		Init: func() counter { return counter{n: 1} },
+		Update: func(m counter, msg Msg) Result[counter] {
+			if msg == "quit" {
+				return Stop(m)
+			}
+			m.n++
+			return Next(m, func() Msg { return "done" })
+		},
+		View: func(m counter, ctx ViewContext) node.Node {
+			return node.Text(fmt.Sprintf("%d", m.n))
+		},
+	}
+	a := p.App()
+
+	model := a.Init()
+	r := a.Update(model, "inc")
+	if r.Model.(counter).n != 2 || len(r.Cmds) != 1 {
+		t.Fatalf("unexpected result %+v", r)
+	}
+	if got := a.view(r.Model, ViewContext{}).Props.Text; got != "2" {
+		t.Fatalf("expected view of the updated model, got %q", got)
+	}
+	if r := a.Update(r.Model, "quit"); !r.Quit {
+		t.Fatal("expected Stop to quit")
+	}
+}
+
+func TestSubscriptionsSync(t *testing.T) {
+	out := make(chan Msg, 8)
+	started := make(chan string, 8)
+	stopped := make(chan string, 8)
+	sub := func(key string) Subscription {
+		return Subscription{Key: key, Run: func(ctx context.Context, send func(Msg)) Msg {
+			started <- key
+			<-ctx.Done()
+			stopped <- key
+			return nil
+		}}
+	}
+
+	var s subscriptions
+	s.sync(context.Background(), []Subscription{sub("a"), sub("b")}, out)
+	s.sync(context.Background(), []Subscription{sub("a"), sub("b"), sub("b")}, out)
+	got := map[string]bool{<-started: true, <-started: true}
+	if !got["a"] || !got["b"] {
+		t.Fatalf("expected a and b to start, got %v", got)
+	}
+
+	s.sync(context.Background(), []Subscription{sub("b"), sub("c")}, out)
+	if key := <-stopped; key != "a" {
+		t.Fatalf("expected a to be cancelled, got %s", key)
+	}
+	if key := <-started; key != "c" {
+		t.Fatalf("expected c to start, got %s", key)
+	}
+	if len(started) != 0 {
+		t.Fatal("expected running subscriptions not to restart")
+	}
+
+	s.stop()
+	got = map[string]bool{<-stopped: true, <-stopped: true}
+	if !got["b"] || !got["c"] {
+		t.Fatalf("expected b and c to stop, got %v", got)
+	}
+}
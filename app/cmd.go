@@ -0,0 +1,220 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// DefaultShutdownTimeout is how long Run waits for in-flight commands when
+// App.ShutdownTimeout is zero.
+const DefaultShutdownTimeout = 2 * time.Second
+
+// ErrShutdownTimeout is returned from Run when commands were still running
+// after the shutdown timeout.
+var ErrShutdownTimeout = errors.New("app: commands still running after shutdown timeout")
+
+// QuitMsg quits the application when it reaches the runtime, with Err as
+// the error returned from Run.
+type QuitMsg struct {
+	Err error
+}
+
+// Quit is a Cmd that quits the application.
+func Quit() Msg {
+	return QuitMsg{}
+}
+
+// contextCmd asks the runtime to run fn with the command context.
+type contextCmd struct {
+	fn func(ctx context.Context) Msg
+}
+
+// CmdContext returns a Cmd whose fn receives a context that is cancelled
+// when the application quits. Use it for work that should stop on exit,
+// such as network requests.
+func CmdContext(fn func(ctx context.Context) Msg) Cmd {
+	return func() Msg {
+		return &contextCmd{fn: fn}
+	}
+}
+
+// batchCmd asks the runtime to run cmds concurrently.
+type batchCmd struct {
+	cmds []Cmd
+}
+
+// sequenceCmd asks the runtime to run cmds one after another.
+type sequenceCmd struct {
+	cmds []Cmd
+}
+
+// sequenceStep carries a message from a Sequence; the run loop starts the
+// rest of the sequence once Update has processed msg.
+type sequenceStep struct {
+	msg  Msg
+	rest []Cmd
+}
+
+// sequenceNext follows a sequenceStep's message in the run loop.
+type sequenceNext struct {
+	rest []Cmd
+}
+
+// Batch returns a Cmd that runs cmds concurrently, delivering each message
+// as it arrives. Nil commands are skipped.
+func Batch(cmds ...Cmd) Cmd {
+	return func() Msg {
+		return &batchCmd{cmds: cmds}
+	}
+}
+
+// Sequence returns a Cmd that runs cmds in order. Each command starts once
+// Update has processed the previous command's message; commands returning
+// nil are followed immediately. A timer command (Tick, Every, FrameTick)
+// holds the rest of the sequence until its first message. Nil commands are
+// skipped.
+func Sequence(cmds ...Cmd) Cmd {
+	return func() Msg {
+		return &sequenceCmd{cmds: cmds}
+	}
+}
+
+// commands runs Cmds and Subs and tracks the Cmds still in flight, so that
+// shutdown can wait for them. With a limit, at most limit Cmds run at once
+// and the rest wait in a queue.
+type commands struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	out    chan Msg
+	wg     sync.WaitGroup
+
+	limit  int
+	mu     sync.Mutex
+	active int
+	queue  []Cmd
+}
+
+func newCommands(ctx context.Context, out chan Msg, limit int) *commands {
+	ctx, cancel := context.WithCancel(ctx)
+	return &commands{ctx: ctx, cancel: cancel, out: out, limit: limit}
+}
+
+// run starts cmd in its own goroutine, or queues it when limit commands are
+// already running.
+func (c *commands) run(cmd Cmd) {
+	if cmd == nil {
+		return
+	}
+	c.wg.Add(1)
+	c.mu.Lock()
+	if c.limit > 0 && c.active >= c.limit {
+		c.queue = append(c.queue, cmd)
+		c.mu.Unlock()
+		return
+	}
+	c.active++
+	c.mu.Unlock()
+	go c.work(cmd)
+}
+
+// work runs cmd, then queued commands until the queue is empty.
+func (c *commands) work(cmd Cmd) {
+	for {
+		if m := c.call(cmd); m != nil {
+			c.send(m)
+		}
+		c.wg.Done()
+
+		c.mu.Lock()
+		if len(c.queue) == 0 {
+			c.active--
+			c.mu.Unlock()
+			return
+		}
+		cmd = c.queue[0]
+		c.queue = c.queue[1:]
+		c.mu.Unlock()
+	}
+}
+
+// call runs exec, turning a panic into a message for the run loop.
+func (c *commands) call(cmd Cmd) (m Msg) {
+	defer func() {
+		if r := recover(); r != nil {
+			m = &panicMsg{value: r, stack: debug.Stack()}
+		}
+	}()
+	return c.exec(cmd)
+}
+
+// exec calls cmd and carries out the request it returns, if any.
+func (c *commands) exec(cmd Cmd) Msg {
+	if cmd == nil {
+		return nil
+	}
+	switch r := cmd().(type) {
+	case *contextCmd:
+		return r.fn(c.ctx)
+	case *batchCmd:
+		for _, b := range r.cmds {
+			c.run(b)
+		}
+		return nil
+	case *sequenceCmd:
+		for i, s := range r.cmds {
+			m := c.exec(s)
+			if step, ok := m.(*sequenceStep); ok {
+				// A nested sequence finishes before this one continues
+				rest := append(append([]Cmd{}, step.rest...), r.cmds[i+1:]...)
+				return &sequenceStep{msg: step.msg, rest: rest}
+			}
+			if m != nil {
+				return &sequenceStep{msg: m, rest: r.cmds[i+1:]}
+			}
+		}
+		return nil
+	default:
+		return r
+	}
+}
+
+// sub starts s in its own goroutine. Subs cannot be cancelled, so shutdown
+// does not wait for them; their messages are dropped once it begins.
+func (c *commands) sub(s Sub) {
+	go func() {
+		defer catch(c.send)
+		if m := s(c.send); m != nil {
+			c.send(m)
+		}
+	}()
+}
+
+// send delivers msg to the run loop, or drops it once shutdown has begun.
+func (c *commands) send(msg Msg) {
+	select {
+	case c.out <- msg:
+	case <-c.ctx.Done():
+	}
+}
+
+// shutdown cancels the command context and waits up to timeout for the
+// running Cmds to return. It reports whether they all did.
+func (c *commands) shutdown(timeout time.Duration) bool {
+	c.cancel()
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-done:
+		return true
+	case <-t.C:
+		return false
+	}
+}
@@ -0,0 +1,390 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stukennedy/tooey/input"
+	"github.com/stukennedy/tooey/node"
+)
+
+// testProgram returns a Program with a blank view and discarded output that
+// reads its keys from the returned pipe, which the caller closes.
+func testProgram(update func(int, Msg) Result[int], opts Options) (*Program[int], *io.PipeWriter) {
+	in, keys := io.Pipe()
+	return &Program[int]{
+		Init:    func() int { return 0 },
+		Update:  update,
+		View:    func(m int, ctx ViewContext) node.Node { return node.Text("") },
+		Options: opts,
+		Output:  &bytes.Buffer{},
+		Input:   in,
+	}, keys
+}
+
+func TestRunWaitsForCommandsOnQuit(t *testing.T) {
+	saved, cancelled := false, false
+	errDone := errors.New("done")
+	p, keys := testProgram(func(m int, msg Msg) Result[int] {
+		if km, ok := msg.(KeyMsg); ok && km.Key.Type == input.RuneKey && km.Key.Rune == 'q' {
+			save := func() Msg {
+				time.Sleep(20 * time.Millisecond)
+				saved = true
+				return "saved"
+			}
+			wait := CmdContext(func(ctx context.Context) Msg {
+				<-ctx.Done()
+				cancelled = true
+				return nil
+			})
+			return Stop(m+1, save, wait)
+		}
+		return Next(m)
+	}, Options{})
+	defer keys.Close()
+	p.OnQuit = func(m int) error {
+		if m != 1 {
+			t.Errorf("expected the final model, got %d", m)
+		}
+		return errDone
+	}
+
+	go keys.Write([]byte("q"))
+	if err := p.Run(context.Background()); !errors.Is(err, errDone) {
+		t.Fatalf("expected OnQuit's error, got %v", err)
+	}
+	if !saved || !cancelled {
+		t.Fatalf("expected commands to finish before Run returned (saved=%v cancelled=%v)", saved, cancelled)
+	}
+}
+
+func TestCommandsShutdownTimeout(t *testing.T) {
+	c := newCommands(context.Background(), make(chan Msg), 0)
+	block := make(chan struct{})
+	defer close(block)
+	c.run(func() Msg {
+		<-block
+		return nil
+	})
+	if c.shutdown(10 * time.Millisecond) {
+		t.Fatal("expected shutdown to time out")
+	}
+}
+
+func TestCommandsSendAfterShutdown(t *testing.T) {
+	c := newCommands(context.Background(), make(chan Msg), 0) // nobody reads
+	c.run(func() Msg { return "late" })
+	if !c.shutdown(time.Second) {
+		t.Fatal("expected a blocked send to be dropped on shutdown")
+	}
+}
+
+func TestBatchRunsAll(t *testing.T) {
+	out := make(chan Msg, 4)
+	c := newCommands(context.Background(), out, 0)
+	msg := func(s string) Cmd { return func() Msg { return s } }
+	c.run(Batch(msg("a"), nil, msg("b")))
+	got := map[Msg]bool{<-out: true, <-out: true}
+	if !got["a"] || !got["b"] {
+		t.Fatalf("expected both messages, got %v", got)
+	}
+}
+
+func TestSequenceWaitsForEachMessage(t *testing.T) {
+	out := make(chan Msg, 4)
+	c := newCommands(context.Background(), out, 0)
+	var ran []string
+	step := func(s string) Cmd {
+		return func() Msg {
+			ran = append(ran, s)
+			return s
+		}
+	}
+	c.run(Sequence(step("build"), func() Msg { return nil }, Sequence(step("push"), step("tag")), step("deploy")))
+
+	for _, want := range []string{"build", "push", "tag", "deploy"} {
+		s, ok := (<-out).(*sequenceStep)
+		if !ok || s.msg != want {
+			t.Fatalf("expected step %q, got %+v", want, s)
+		}
+		if len(out) != 0 || ran[len(ran)-1] != want {
+			t.Fatalf("expected the sequence to wait after %q, ran %v", want, ran)
+		}
+		// The run loop starts the rest once Update has seen the message
+		c.run(Sequence(s.rest...))
+	}
+	if !c.shutdown(time.Second) || len(out) != 0 {
+		t.Fatal("expected the sequence to end")
+	}
+}
+
+func TestSequenceWaitsForTimers(t *testing.T) {
+	var seen []Msg
+	p, keys := testProgram(func(m int, msg Msg) Result[int] {
+		switch msg {
+		case "go":
+			seen = append(seen, msg)
+			tick := Tick(20*time.Millisecond, func(time.Time) Msg { return "tick" })
+			return Next(m, Sequence(tick, Emit("after")))
+		case "tick":
+			seen = append(seen, msg)
+		case "after":
+			seen = append(seen, msg)
+			return Stop(m)
+		}
+		return Next(m)
+	}, Options{})
+	defer keys.Close()
+	p.Send("go")
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 3 || seen[1] != "tick" || seen[2] != "after" {
+		t.Fatalf("expected the sequence to continue after the tick, got %v", seen)
+	}
+}
+
+func TestMaxConcurrentCmds(t *testing.T) {
+	out := make(chan Msg, 8)
+	c := newCommands(context.Background(), out, 2)
+	var mu sync.Mutex
+	running, peak := 0, 0
+	for range 6 {
+		c.run(func() Msg {
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return "done"
+		})
+	}
+	for range 6 {
+		<-out
+	}
+	if peak != 2 {
+		t.Fatalf("expected at most 2 commands running, got %d", peak)
+	}
+}
+
+func TestFrameTickAfterRender(t *testing.T) {
+	// The tick is requested in a pass that renders, with nothing else to wake
+	// the loop for the next frame
+	framed := false
+	p, keys := testProgram(func(m int, msg Msg) Result[int] {
+		switch msg {
+		case "go":
+			slow := func() Msg {
+				time.Sleep(100 * time.Millisecond)
+				return nil
+			}
+			return Next(m, Sequence(slow, FrameTick(func(time.Time) Msg { return "frame" })))
+		case "frame":
+			framed = true
+			return Stop(m)
+		}
+		return Next(m)
+	}, Options{})
+	defer keys.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	p.Send("go")
+	p.Run(ctx)
+	if !framed {
+		t.Fatal("expected the frame tick to be delivered")
+	}
+}
+
+func TestExecHandsOverInput(t *testing.T) {
+	in, keys, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	defer keys.Close()
+	ready, readyW := io.Pipe()
+
+	var got bytes.Buffer
+	var exitErr error
+	p, _ := testProgram(func(m int, msg Msg) Result[int] {
+		switch msg := msg.(type) {
+		case KeyMsg:
+			cmd := exec.Command("sh", "-c", "echo ready >&2; head -c 3")
+			cmd.Stdout, cmd.Stderr = &got, readyW
+			return Next(m, Exec(cmd, func(err error) Msg { return exitMsg{err} }))
+		case exitMsg:
+			exitErr = msg.err
+			return Stop(m)
+		}
+		return Next(m)
+	}, Options{})
+	p.Input = in
+
+	go func() {
+		keys.Write([]byte("e"))
+		// The app has stopped reading once the child is running
+		bufio.NewReader(ready).ReadString('\n')
+		keys.Write([]byte("abc"))
+	}()
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if exitErr != nil || got.String() != "abc" {
+		t.Fatalf("expected the child to read the input, got %q, %v", got.String(), exitErr)
+	}
+}
+
+type exitMsg struct{ err error }
+
+func TestRunRecoversPanics(t *testing.T) {
+	stderr := os.Stderr
+	devnull, _ := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	os.Stderr = devnull
+	defer func() { os.Stderr = stderr }()
+
+	for name, update := range map[string]func(int, Msg) Result[int]{
+		"update": func(m int, msg Msg) Result[int] {
+			panic("boom")
+		},
+		"command": func(m int, msg Msg) Result[int] {
+			return Next(m, func() Msg { panic("boom") })
+		},
+	} {
+		p, keys := testProgram(update, Options{})
+		go keys.Write([]byte("x"))
+		if err := p.Run(context.Background()); !errors.Is(err, ErrPanic) {
+			t.Errorf("%s: expected ErrPanic, got %v", name, err)
+		}
+		keys.Close()
+	}
+}
+
+func TestSendAndQuitFromOutside(t *testing.T) {
+	final := 0
+	p, keys := testProgram(func(m int, msg Msg) Result[int] {
+		if n, ok := msg.(int); ok {
+			m += n
+		}
+		return Next(m)
+	}, Options{})
+	defer keys.Close()
+	p.OnQuit = func(m int) error { final = m; return nil }
+
+	p.Send(1) // queued until the program runs
+	p.Start(context.Background())
+	p.Send(2)
+	p.Quit()
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if final != 3 {
+		t.Fatalf("expected both messages before quitting, got %d", final)
+	}
+	p.Send(4) // returns once the program has exited
+}
+
+func TestRunTwice(t *testing.T) {
+	total := 0
+	p, keys := testProgram(func(m int, msg Msg) Result[int] {
+		if n, ok := msg.(int); ok {
+			m += n
+		}
+		return Next(m)
+	}, Options{})
+	defer keys.Close()
+	p.OnQuit = func(m int) error { total += m; return nil }
+
+	// App shares the program's inbox
+	p.Send(1)
+	p.Quit()
+	if err := p.App().Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second run gets a fresh inbox
+	p.Start(context.Background())
+	if err := p.Run(context.Background()); !errors.Is(err, ErrRunning) {
+		t.Fatalf("expected ErrRunning while running, got %v", err)
+	}
+	p.Send(2)
+	p.Quit()
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 {
+		t.Fatalf("expected both runs to see their messages, got %d", total)
+	}
+}
+
+func TestRenderOnlyWhenChanged(t *testing.T) {
+	var mu sync.Mutex
+	now := time.Unix(1000, 0)
+	advance := func(d time.Duration) {
+		mu.Lock()
+		now = now.Add(d)
+		mu.Unlock()
+	}
+
+	frames := make(chan int, 64)
+	burst := make(chan struct{})
+	p, keys := testProgram(func(m int, msg Msg) Result[int] {
+		if n, ok := msg.(int); ok {
+			m = n
+			if n == 50 {
+				close(burst)
+			}
+		}
+		return Next(m)
+	}, Options{MaxFPS: 100})
+	defer keys.Close()
+	p.View = func(m int, ctx ViewContext) node.Node {
+		frames <- m
+		return node.Text("")
+	}
+	p.App().clock = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	p.Start(context.Background())
+	if m := <-frames; m != 0 {
+		t.Fatalf("expected the first frame to show the initial model, got %d", m)
+	}
+
+	// The clock is stopped, so the burst waits for the next frame
+	for i := 1; i <= 50; i++ {
+		p.Send(i)
+	}
+	<-burst
+	select {
+	case m := <-frames:
+		t.Fatalf("expected no frame within the frame interval, got one showing %d", m)
+	default:
+	}
+	advance(10 * time.Millisecond)
+	if m := <-frames; m != 50 {
+		t.Fatalf("expected one frame showing the last message, got %d", m)
+	}
+
+	// Idle: no more frames, however much time passes
+	advance(time.Second)
+	p.Quit()
+	p.Wait()
+	select {
+	case m := <-frames:
+		t.Fatalf("expected no frames while idle, got one showing %d", m)
+	default:
+	}
+}
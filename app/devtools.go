@@ -0,0 +1,244 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/stukennedy/tooey/cell"
+	"github.com/stukennedy/tooey/focus"
+	"github.com/stukennedy/tooey/input"
+	"github.com/stukennedy/tooey/layout"
+	"github.com/stukennedy/tooey/node"
+)
+
+// devLogSize is how many messages the devtools message log keeps.
+const devLogSize = 50
+
+// devPanelWidth is the widest the devtools panel gets; it never takes more
+// than half the screen.
+const devPanelWidth = 48
+
+// frameStats are the timings of one rendered frame. Update is the time
+// spent in Update since the previous frame.
+type frameStats struct {
+	Update, View, Layout, Paint, Diff, Render time.Duration
+	Bytes                                     int // written by Render
+}
+
+// devEntry is a message in the devtools log.
+type devEntry struct {
+	at   time.Time
+	typ  string
+	body string
+}
+
+// devItem is a row of the layout tree inspector.
+type devItem struct {
+	depth int
+	label string
+	rect  layout.Rect
+}
+
+// devtools is the developer tools overlay enabled by Options.DevTools. It
+// shows frame timings, the focus state, the layout tree of the last frame
+// and the latest messages.
+type devtools struct {
+	toggle   input.Key
+	open     bool
+	stats    frameStats  // the last frame
+	frames   []time.Time // frames rendered in the last second
+	log      []devEntry
+	items    []devItem
+	selected int
+}
+
+func newDevtools(o Options) *devtools {
+	key := o.DevToolsKey
+	if key == (input.Key{}) {
+		key = input.Key{Type: input.F12}
+	}
+	return &devtools{toggle: key}
+}
+
+// keys removes the key messages the overlay handles from msgs.
+func (d *devtools) keys(msgs []Msg) []Msg {
+	out := msgs[:0]
+	for _, msg := range msgs {
+		if km, ok := msg.(KeyMsg); ok && d.key(km.Key) {
+			continue
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+// key handles k and reports whether the overlay consumed it. While open,
+// the navigation keys move the inspector's selection.
+func (d *devtools) key(k input.Key) bool {
+	if k == d.toggle {
+		d.open = !d.open
+		return true
+	}
+	if !d.open {
+		return false
+	}
+	switch k.Type {
+	case input.Up:
+		d.selected--
+	case input.Down:
+		d.selected++
+	case input.PageUp:
+		d.selected -= 10
+	case input.PageDown:
+		d.selected += 10
+	case input.Home:
+		d.selected = 0
+	case input.End:
+		d.selected = len(d.items) - 1
+	default:
+		return false
+	}
+	d.selected = max(0, min(d.selected, len(d.items)-1))
+	return true
+}
+
+// record adds msg, about to be passed to Update, to the log.
+func (d *devtools) record(msg Msg, now time.Time) {
+	body := strings.ReplaceAll(fmt.Sprintf("%+v", msg), "\n", " ")
+	if len(d.log) == devLogSize {
+		d.log = append(d.log[:0], d.log[1:]...)
+	}
+	d.log = append(d.log, devEntry{at: now, typ: fmt.Sprintf("%T", msg), body: node.Truncate(body, 200)})
+}
+
+// frame records the stats of a frame rendered at now.
+func (d *devtools) frame(s frameStats, now time.Time) {
+	d.stats = s
+	i := 0
+	for i < len(d.frames) && now.Sub(d.frames[i]) >= time.Second {
+		i++
+	}
+	d.frames = append(d.frames[i:], now)
+}
+
+// paint draws the overlay onto buf while it is open: the selected node of
+// lt is shown in reverse video, and the panel docks on the side of the
+// screen away from it.
+func (d *devtools) paint(buf *cell.Buffer, lt layout.LayoutNode, fm *focus.Manager, theme Theme) {
+	if !d.open {
+		return
+	}
+	d.items = flatten(lt, 0, d.items[:0])
+	d.selected = max(0, min(d.selected, len(d.items)-1))
+	sel := d.items[d.selected].rect
+	for y := sel.Y; y < sel.Y+sel.H; y++ {
+		for x := sel.X; x < sel.X+sel.W; x++ {
+			c := buf.Get(x, y)
+			c.Style ^= node.Reverse
+			buf.Set(x, y, c)
+		}
+	}
+
+	w := min(devPanelWidth, buf.Width/2)
+	if w < 12 || buf.Height < 3 {
+		return
+	}
+	x0 := buf.Width - w
+	if sel.X+sel.W/2 >= buf.Width/2 && sel.W < buf.Width {
+		x0 = 0
+	}
+	panel := cell.NewBuffer(w, buf.Height)
+	cell.Paint(panel, layout.Layout(d.panel(w, buf.Height, fm, theme), w, buf.Height))
+	for y := 0; y < panel.Height; y++ {
+		for x := 0; x < panel.Width; x++ {
+			buf.Set(x0+x, y, panel.Get(x, y))
+		}
+	}
+}
+
+// panel builds the overlay's content for a w×h panel.
+func (d *devtools) panel(w, h int, fm *focus.Manager, theme Theme) node.Node {
+	inner := w - 2
+	text := func(s string, fg, bg node.Color, style node.StyleFlags) node.Node {
+		return node.TextStyled(node.Truncate(s, inner), fg, bg, style)
+	}
+	s := d.stats
+	current := fm.Current()
+	if current == "" {
+		current = "(none)"
+	}
+	rows := []node.Node{
+		text(fmt.Sprintf("fps %d  update %s  view %s", len(d.frames), ms(s.Update), ms(s.View)), theme.FG, 0, 0),
+		text(fmt.Sprintf("layout %s  paint %s  diff %s", ms(s.Layout), ms(s.Paint), ms(s.Diff)), theme.FG, 0, 0),
+		text(fmt.Sprintf("render %s  %d bytes", ms(s.Render), s.Bytes), theme.FG, 0, 0),
+		text("focus "+current, theme.FG, 0, 0),
+		text(strings.Join(fm.Focusables(), " "), theme.Muted, 0, 0),
+	}
+
+	// The rest is shared by the tree and the log, below their headings
+	room := max(h-2-len(rows)-2, 0)
+	treeRows := room / 2
+	logRows := room - treeRows
+
+	rows = append(rows, text(fmt.Sprintf("layout %d/%d", d.selected+1, len(d.items)), theme.Accent, 0, node.Bold))
+	first := max(0, min(d.selected-treeRows/2, len(d.items)-treeRows))
+	for i := first; i < min(len(d.items), first+treeRows); i++ {
+		it := d.items[i]
+		label := strings.Repeat(" ", min(it.depth, 8)) + it.label
+		if i == d.selected {
+			rows = append(rows, text(label, theme.SelectionFG, theme.SelectionBG, 0))
+		} else {
+			rows = append(rows, text(label, theme.FG, 0, 0))
+		}
+	}
+
+	rows = append(rows, text("messages", theme.Accent, 0, node.Bold))
+	for _, e := range d.log[max(0, len(d.log)-logRows):] {
+		rows = append(rows, text(e.at.Format("15:04:05.000")+" "+e.typ+" "+e.body, theme.FG, 0, 0))
+	}
+
+	return node.Box(node.BorderRounded, node.Column(rows...)).
+		WithTitle(" devtools ", node.TextAlignLeft).
+		WithBorderColor(theme.Border, 0)
+}
+
+// flatten appends ln and its descendants, layers last, to items.
+func flatten(ln layout.LayoutNode, depth int, items []devItem) []devItem {
+	n := ln.Node
+	label := n.Type.String()
+	if n.Props.Key != "" {
+		label += " #" + n.Props.Key
+	}
+	if n.Type == node.TextNode {
+		label += fmt.Sprintf(" %q", node.Truncate(n.Props.Text, 12))
+	}
+	r := ln.Rect
+	label += fmt.Sprintf(" %d,%d %dx%d", r.X, r.Y, r.W, r.H)
+	items = append(items, devItem{depth: depth, label: label, rect: r})
+	for _, c := range ln.Children {
+		items = flatten(c, depth+1, items)
+	}
+	for _, l := range ln.Layers {
+		items = flatten(l, depth+1, items)
+	}
+	return items
+}
+
+// ms formats d in milliseconds.
+func ms(d time.Duration) string {
+	return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
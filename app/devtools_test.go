@@ -0,0 +1,101 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stukennedy/tooey/cell"
+	"github.com/stukennedy/tooey/focus"
+	"github.com/stukennedy/tooey/input"
+	"github.com/stukennedy/tooey/layout"
+	"github.com/stukennedy/tooey/node"
+)
+
+func TestDevtoolsKeys(t *testing.T) {
+	d := newDevtools(Options{DevTools: true})
+	d.items = make([]devItem, 5)
+
+	down := KeyMsg{Key: input.Key{Type: input.Down}}
+	msgs := d.keys([]Msg{down})
+	if len(msgs) != 1 {
+		t.Fatal("expected keys to reach Update while the overlay is closed")
+	}
+
+	msgs = d.keys([]Msg{KeyMsg{Key: input.Key{Type: input.F12}}, down, down, KeyMsg{Key: input.Key{Type: input.RuneKey, Rune: 'x'}}})
+	if !d.open {
+		t.Fatal("expected F12 to open the overlay")
+	}
+	if len(msgs) != 1 || msgs[0] != (KeyMsg{Key: input.Key{Type: input.RuneKey, Rune: 'x'}}) {
+		t.Fatalf("expected only the rune key to pass through, got %v", msgs)
+	}
+	if d.selected != 2 {
+		t.Fatalf("expected selection 2, got %d", d.selected)
+	}
+	d.key(input.Key{Type: input.PageDown})
+	if d.selected != 4 {
+		t.Fatalf("expected selection clamped to 4, got %d", d.selected)
+	}
+
+	custom := newDevtools(Options{DevTools: true, DevToolsKey: input.Key{Type: input.F2}})
+	if custom.key(input.Key{Type: input.F12}) || !custom.key(input.Key{Type: input.F2}) {
+		t.Fatal("expected DevToolsKey to replace F12")
+	}
+}
+
+func TestDevtoolsLog(t *testing.T) {
+	d := newDevtools(Options{})
+	now := time.Now()
+	for i := 0; i < devLogSize+5; i++ {
+		d.record(ScrollMsg{Delta: i}, now)
+	}
+	if len(d.log) != devLogSize {
+		t.Fatalf("expected %d entries, got %d", devLogSize, len(d.log))
+	}
+	last := d.log[len(d.log)-1]
+	if last.typ != "app.ScrollMsg" || last.body != "{Delta:54}" {
+		t.Fatalf("unexpected entry %+v", last)
+	}
+}
+
+func TestDevtoolsPaint(t *testing.T) {
+	tree := node.Column(
+		node.Text("left").WithKey("a").WithFocusable(),
+		node.Text("right"),
+	)
+	lt := layout.Layout(tree, 100, 20)
+	fm := focus.NewManager()
+	fm.Update(lt)
+
+	d := newDevtools(Options{})
+	buf := cell.NewBuffer(100, 20)
+	cell.Paint(buf, lt)
+	d.paint(buf, lt, fm, DefaultTheme())
+	if buf.Get(0, 0).Style&node.Reverse != 0 {
+		t.Fatal("expected nothing painted while closed")
+	}
+
+	d.open = true
+	d.selected = 1
+	d.paint(buf, lt, fm, DefaultTheme())
+	if len(d.items) != 3 {
+		t.Fatalf("expected 3 inspector rows, got %d", len(d.items))
+	}
+	if buf.Get(0, 0).Style&node.Reverse == 0 {
+		t.Fatal("expected the selected node to be highlighted")
+	}
+
+	var sb strings.Builder
+	for y := 0; y < buf.Height; y++ {
+		for x := 100 - devPanelWidth; x < 100; x++ {
+			sb.WriteRune(buf.Get(x, y).Rune)
+		}
+		sb.WriteByte('\n')
+	}
+	screen := sb.String()
+	for _, want := range []string{"devtools", "fps", "focus a", `text #a "left" 0,0`} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("expected panel to contain %q:\n%s", want, screen)
+		}
+	}
+}
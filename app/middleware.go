@@ -0,0 +1,41 @@
+package app
+
+import "github.com/stukennedy/tooey/input"
+
+// UpdateFunc is the signature of App.Update.
+type UpdateFunc func(model interface{}, msg Msg) UpdateResult
+
+// Middleware wraps Update. It sees every message before and the result
+// after next runs, and may swallow the message by not calling next,
+// transform it, inject messages with Emit or add commands to the result.
+type Middleware func(next UpdateFunc) UpdateFunc
+
+// Emit returns a Cmd that delivers msg as a new message.
+func Emit(msg Msg) Cmd {
+	return func() Msg {
+		return msg
+	}
+}
+
+// OnKey returns a Middleware that handles key k with fn instead of
+// passing it to Update, for global keybindings such as a help overlay.
+func OnKey(k input.Key, fn func(model interface{}) UpdateResult) Middleware {
+	return func(next UpdateFunc) UpdateFunc {
+		return func(model interface{}, msg Msg) UpdateResult {
+			if km, ok := msg.(KeyMsg); ok && km.Key == k {
+				return fn(model)
+			}
+			return next(model, msg)
+		}
+	}
+}
+
+// update returns Update wrapped in the app's middleware, the first
+// middleware outermost.
+func (a *App) update() UpdateFunc {
+	update := UpdateFunc(a.Update)
+	for i := len(a.Middleware) - 1; i >= 0; i-- {
+		update = a.Middleware[i](update)
+	}
+	return update
+}
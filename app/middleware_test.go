@@ -0,0 +1,73 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stukennedy/tooey/input"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	var trace []string
+	tag := func(name string) Middleware {
+		return func(next UpdateFunc) UpdateFunc {
+			return func(model interface{}, msg Msg) UpdateResult {
+				trace = append(trace, name+">")
+				r := next(model, msg)
+				trace = append(trace, "<"+name)
+				return r
+			}
+		}
+	}
+	a := &App{
+		Update: func(model interface{}, msg Msg) UpdateResult {
+			trace = append(trace, "update")
+			return NoCmd(model)
+		},
+		Middleware: []Middleware{tag("log"), tag("undo")},
+	}
+	a.update()("m", "msg")
+	want := []string{"log>", "undo>", "update", "<undo", "<log"}
+	if len(trace) != len(want) {
+		t.Fatalf("expected %v, got %v", want, trace)
+	}
+	for i := range want {
+		if trace[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, trace)
+		}
+	}
+}
+
+func TestMiddlewareSwallowTransformInject(t *testing.T) {
+	var seen []Msg
+	a := &App{
+		Update: func(model interface{}, msg Msg) UpdateResult {
+			seen = append(seen, msg)
+			return NoCmd(model)
+		},
+		Middleware: []Middleware{
+			OnKey(input.Key{Type: input.RuneKey, Rune: '?'}, func(model interface{}) UpdateResult {
+				return WithCmd(model, Emit("help"))
+			}),
+			func(next UpdateFunc) UpdateFunc {
+				return func(model interface{}, msg Msg) UpdateResult {
+					if s, ok := msg.(string); ok {
+						msg = "seen:" + s
+					}
+					r := next(model, msg)
+					r.Cmds = append(r.Cmds, Emit("analytics"))
+					return r
+				}
+			},
+		},
+	}
+	update := a.update()
+
+	r := update("m", KeyMsg{Key: input.Key{Type: input.RuneKey, Rune: '?'}})
+	if len(seen) != 0 || len(r.Cmds) != 1 || r.Cmds[0]() != "help" {
+		t.Fatalf("expected the key to be swallowed and help emitted, saw %v", seen)
+	}
+	r = update("m", "x")
+	if len(seen) != 1 || seen[0] != "seen:x" || len(r.Cmds) != 1 || r.Cmds[0]() != "analytics" {
+		t.Fatalf("expected a transformed message and an extra command, saw %v", seen)
+	}
+}
@@ -0,0 +1,174 @@
+package app
+
+import (
+	"slices"
+	"time"
+
+	"github.com/stukennedy/tooey/input"
+)
+
+// CtrlCMode chooses what Ctrl+C does.
+type CtrlCMode int
+
+const (
+	// CtrlCQuit quits the app (the default).
+	CtrlCQuit CtrlCMode = iota
+	// CtrlCMsg delivers an InterruptMsg to Update, e.g. to cancel a running
+	// job; Update decides whether to quit.
+	CtrlCMsg
+	// CtrlCConfirm delivers an InterruptMsg with Confirm set, and quits if
+	// Ctrl+C is pressed again within Options.ConfirmTimeout.
+	CtrlCConfirm
+)
+
+// DefaultConfirmTimeout is the CtrlCConfirm window when
+// Options.ConfirmTimeout is zero.
+const DefaultConfirmTimeout = 2 * time.Second
+
+// InterruptMsg is sent for Ctrl+C unless the CtrlC option is CtrlCQuit.
+// Confirm is set in CtrlCConfirm mode, when pressing Ctrl+C again quits.
+type InterruptMsg struct {
+	Confirm bool
+}
+
+// FocusKeys are the keys the runtime uses to move focus. Focus keys are
+// still delivered to Update.
+type FocusKeys struct {
+	Next []input.Key // focus the next focusable node
+	Prev []input.Key // focus the previous focusable node
+	Pop  []input.Key // leave the current focus context
+}
+
+// DefaultFocusKeys returns Tab, Shift+Tab and Escape.
+func DefaultFocusKeys() FocusKeys {
+	return FocusKeys{
+		Next: []input.Key{{Type: input.Tab}},
+		Prev: []input.Key{{Type: input.ShiftTab}},
+		Pop:  []input.Key{{Type: input.Escape}},
+	}
+}
+
+// DefaultMaxFPS is the frame rate cap when Options.MaxFPS is zero.
+const DefaultMaxFPS = 30
+
+// Options configures how the runtime handles keys before Update sees them
+// and how often it renders. The zero value keeps the defaults.
+type Options struct {
+	// CtrlC chooses what Ctrl+C does (defaults to CtrlCQuit).
+	CtrlC CtrlCMode
+
+	// ConfirmTimeout is how long a CtrlCConfirm confirmation waits for the
+	// second Ctrl+C (defaults to DefaultConfirmTimeout).
+	ConfirmTimeout time.Duration
+
+	// FocusKeys remaps the focus keys (defaults to DefaultFocusKeys()).
+	FocusKeys FocusKeys
+
+	// DisableFocusKeys stops the runtime moving focus on key presses, so
+	// Tab and Escape reach Update without side effects.
+	DisableFocusKeys bool
+
+	// DisableSuspend delivers Ctrl+Z to Update as a KeyMsg instead of
+	// suspending the app.
+	DisableSuspend bool
+
+	// MaxFPS caps how many frames are rendered per second (defaults to
+	// DefaultMaxFPS). Frames are only rendered when something changed.
+	MaxFPS int
+
+	// DevTools enables the developer tools overlay, toggled with
+	// DevToolsKey. While it is open, the navigation keys move through the
+	// layout tree instead of reaching Update.
+	DevTools bool
+
+	// DevToolsKey toggles the overlay (defaults to F12).
+	DevToolsKey input.Key
+}
+
+// frameInterval returns the minimum time between frames.
+func (o Options) frameInterval() time.Duration {
+	fps := o.MaxFPS
+	if fps <= 0 {
+		fps = DefaultMaxFPS
+	}
+	return time.Second / time.Duration(fps)
+}
+
+// keyMsg translates a key read from the terminal into its message. last
+// holds the time of the previous unconfirmed Ctrl+C.
+func (o Options) keyMsg(k input.Key, last *time.Time) Msg {
+	switch k.Type {
+	case input.FocusIn:
+		return FocusMsg{Focused: true}
+	case input.FocusOut:
+		return FocusMsg{Focused: false}
+	case input.MouseScrollUp:
+		return ScrollMsg{Delta: 3}
+	case input.MouseScrollDown:
+		return ScrollMsg{Delta: -3}
+	case input.CtrlC:
+		return o.interrupt(last, time.Now())
+	case input.CtrlZ:
+		return o.ctrlZ(k)
+	default:
+		return KeyMsg{Key: k}
+	}
+}
+
+// interrupt returns the message for a Ctrl+C pressed at now. last holds the
+// time of the previous unconfirmed press.
+func (o Options) interrupt(last *time.Time, now time.Time) Msg {
+	switch o.CtrlC {
+	case CtrlCMsg:
+		return InterruptMsg{}
+	case CtrlCConfirm:
+		timeout := o.ConfirmTimeout
+		if timeout <= 0 {
+			timeout = DefaultConfirmTimeout
+		}
+		if !last.IsZero() && now.Sub(*last) <= timeout {
+			return QuitMsg{}
+		}
+		*last = now
+		return InterruptMsg{Confirm: true}
+	default:
+		return QuitMsg{}
+	}
+}
+
+// ctrlZ returns the message for a Ctrl+Z key press.
+func (o Options) ctrlZ(k input.Key) Msg {
+	if o.DisableSuspend {
+		return KeyMsg{Key: k}
+	}
+	return suspendMsg{}
+}
+
+type focusAction int
+
+const (
+	focusNone focusAction = iota
+	focusNext
+	focusPrev
+	focusPop
+)
+
+// focusAction returns what k does to focus.
+func (o Options) focusAction(k input.Key) focusAction {
+	if o.DisableFocusKeys {
+		return focusNone
+	}
+	keys := o.FocusKeys
+	if keys.Next == nil && keys.Prev == nil && keys.Pop == nil {
+		keys = DefaultFocusKeys()
+	}
+	switch {
+	case slices.Contains(keys.Next, k):
+		return focusNext
+	case slices.Contains(keys.Prev, k):
+		return focusPrev
+	case slices.Contains(keys.Pop, k):
+		return focusPop
+	}
+	return focusNone
+}
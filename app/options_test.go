@@ -0,0 +1,71 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stukennedy/tooey/input"
+)
+
+func TestInterruptModes(t *testing.T) {
+	var last time.Time
+	now := time.Now()
+	if m := (Options{}).interrupt(&last, now); m != (QuitMsg{}) {
+		t.Fatalf("expected Ctrl+C to quit by default, got %v", m)
+	}
+	if m := (Options{CtrlC: CtrlCMsg}).interrupt(&last, now); m != (InterruptMsg{}) {
+		t.Fatalf("expected an InterruptMsg, got %v", m)
+	}
+
+	confirm := Options{CtrlC: CtrlCConfirm, ConfirmTimeout: time.Second}
+	if m := confirm.interrupt(&last, now); m != (InterruptMsg{Confirm: true}) {
+		t.Fatalf("expected a confirmation prompt, got %v", m)
+	}
+	if m := confirm.interrupt(&last, now.Add(3*time.Second)); m != (InterruptMsg{Confirm: true}) {
+		t.Fatalf("expected a late second press to prompt again, got %v", m)
+	}
+	if m := confirm.interrupt(&last, now.Add(3500*time.Millisecond)); m != (QuitMsg{}) {
+		t.Fatalf("expected a confirmed Ctrl+C to quit, got %v", m)
+	}
+}
+
+func TestFocusKeys(t *testing.T) {
+	tab, esc := input.Key{Type: input.Tab}, input.Key{Type: input.Escape}
+	if a := (Options{}).focusAction(tab); a != focusNext {
+		t.Fatalf("expected Tab to move focus by default, got %v", a)
+	}
+	if a := (Options{DisableFocusKeys: true}).focusAction(esc); a != focusNone {
+		t.Fatalf("expected focus keys to be disabled, got %v", a)
+	}
+
+	remapped := Options{FocusKeys: FocusKeys{
+		Next: []input.Key{{Type: input.RuneKey, Rune: ']'}},
+		Prev: []input.Key{{Type: input.RuneKey, Rune: '['}},
+	}}
+	if a := remapped.focusAction(input.Key{Type: input.RuneKey, Rune: ']'}); a != focusNext {
+		t.Fatalf("expected ] to move focus, got %v", a)
+	}
+	if remapped.focusAction(tab) != focusNone || remapped.focusAction(esc) != focusNone {
+		t.Fatal("expected Tab and Escape to be left to Update")
+	}
+}
+
+func TestCtrlZOption(t *testing.T) {
+	k := input.Key{Type: input.CtrlZ}
+	if m := (Options{}).ctrlZ(k); m != (suspendMsg{}) {
+		t.Fatalf("expected Ctrl+Z to suspend, got %v", m)
+	}
+	if m := (Options{DisableSuspend: true}).ctrlZ(k); m != (KeyMsg{Key: k}) {
+		t.Fatalf("expected Ctrl+Z as a key, got %v", m)
+	}
+}
+
+func TestKeyMsgTranslatesScroll(t *testing.T) {
+	var last time.Time
+	if m := (Options{}).keyMsg(input.Key{Type: input.MouseScrollDown}, &last); m != (ScrollMsg{Delta: -3}) {
+		t.Fatalf("expected a ScrollMsg, got %v", m)
+	}
+	if m := (Options{}).keyMsg(input.Key{Type: input.FocusIn}, &last); m != (FocusMsg{Focused: true}) {
+		t.Fatalf("expected a FocusMsg, got %v", m)
+	}
+}
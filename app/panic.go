@@ -0,0 +1,40 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+)
+
+// ErrPanic is wrapped by the error Run returns after recovering from a
+// panic in Update, View or a command. The terminal is restored and the
+// panic printed to stderr first.
+var ErrPanic = errors.New("app: panic")
+
+// ErrSignal is wrapped by the error Run returns after quitting on SIGINT,
+// SIGTERM or SIGHUP.
+var ErrSignal = errors.New("app: received signal")
+
+// panicMsg carries a panic from a command goroutine to the run loop.
+type panicMsg struct {
+	value interface{}
+	stack []byte
+}
+
+// catch recovers a panic in a command goroutine and sends it to the run
+// loop, which restores the terminal before reporting it.
+func catch(send func(Msg)) {
+	if r := recover(); r != nil {
+		send(&panicMsg{value: r, stack: debug.Stack()})
+	}
+}
+
+// report prints a recovered panic to stderr and returns the error for Run.
+func report(r interface{}, stack []byte) error {
+	if p, ok := r.(*panicMsg); ok {
+		r, stack = p.value, p.stack
+	}
+	fmt.Fprintf(os.Stderr, "panic: %v\n\n%s", r, stack)
+	return fmt.Errorf("%w: %v", ErrPanic, r)
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/stukennedy/tooey/node"
+)
+
+// Result is returned from a Program's Update: the new model, optional
+// commands and subscriptions, and whether to quit. A non-nil Err quits and
+// is returned from Run.
+type Result[M any] struct {
+	Model M
+	Cmds  []Cmd
+	Subs  []Sub
+	Quit  bool
+	Err   error
+}
+
+// Next returns a Result that continues with model and runs cmds.
+func Next[M any](model M, cmds ...Cmd) Result[M] {
+	return Result[M]{Model: model, Cmds: cmds}
+}
+
+// Stop returns a Result that quits the program once cmds have finished.
+func Stop[M any](model M, cmds ...Cmd) Result[M] {
+	return Result[M]{Model: model, Cmds: cmds, Quit: true}
+}
+
+// Program is a type-safe application over a model of type M. It runs on the
+// same loop as App; use App() to get the equivalent App.
+type Program[M any] struct {
+	// Init returns the initial model.
+	Init func() M
+
+	// Update processes a message and returns the new model + optional commands.
+	Update func(model M, msg Msg) Result[M]
+
+	// Middleware wraps Update; models reach it as interface{} holding an M.
+	Middleware []Middleware
+
+	// View renders the model to a node tree.
+	View func(model M, ctx ViewContext) node.Node
+
+	// Subscriptions declares the subscriptions the model needs.
+	Subscriptions func(model M) []Subscription
+
+	// Theme is passed to View (defaults to DefaultTheme()).
+	Theme Theme
+
+	// OnQuit is called with the final model on quit. Its error is returned
+	// from Run.
+	OnQuit func(model M) error
+
+	// ShutdownTimeout bounds how long Run waits for in-flight commands on
+	// quit (defaults to DefaultShutdownTimeout).
+	ShutdownTimeout time.Duration
+
+	// MaxConcurrentCmds caps how many Cmds run at once (zero means no limit).
+	MaxConcurrentCmds int
+
+	// Output writer (defaults to os.Stdout).
+	Output io.Writer
+
+	// Input reader (defaults to os.Stdin).
+	Input io.Reader
+
+	// Options configures Ctrl+C, Ctrl+Z and the focus keys.
+	Options Options
+
+	once sync.Once
+	app  *App
+}
+
+// App returns the App that runs the program. It is built from the
+// program's fields on the first call to App, Run, Send, Quit, Start or
+// Wait, and shared by all of them; later changes to the fields have no
+// effect.
+func (p *Program[M]) App() *App {
+	p.once.Do(func() {
+		p.app = p.newApp()
+	})
+	return p.app
+}
+
+func (p *Program[M]) newApp() *App {
+	a := &App{
+		Init: func() interface{} {
+			return p.Init()
+		},
+		Update: func(model interface{}, msg Msg) UpdateResult {
+			r := p.Update(model.(M), msg)
+			return UpdateResult{Model: r.Model, Cmds: r.Cmds, Subs: r.Subs, Quit: r.Quit, Err: r.Err}
+		},
+		ViewCtx: func(model interface{}, ctx ViewContext) node.Node {
+			return p.View(model.(M), ctx)
+		},
+		Middleware:        p.Middleware,
+		Theme:             p.Theme,
+		ShutdownTimeout:   p.ShutdownTimeout,
+		MaxConcurrentCmds: p.MaxConcurrentCmds,
+		Output:            p.Output,
+		Input:             p.Input,
+		Options:           p.Options,
+	}
+	if p.OnQuit != nil {
+		a.OnQuit = func(model interface{}) error {
+			return p.OnQuit(model.(M))
+		}
+	}
+	if p.Subscriptions != nil {
+		a.Subscriptions = func(model interface{}) []Subscription {
+			return p.Subscriptions(model.(M))
+		}
+	}
+	return a
+}
+
+// Run starts the program's main loop.
+func (p *Program[M]) Run(ctx context.Context) error {
+	return p.App().Run(ctx)
+}
+
+// Send delivers msg to Update from any goroutine; see App.Send.
+func (p *Program[M]) Send(msg Msg) {
+	p.App().Send(msg)
+}
+
+// Quit asks the program to quit.
+func (p *Program[M]) Quit() {
+	p.App().Quit()
+}
+
+// Start runs the program in a new goroutine; use Wait for its result.
+func (p *Program[M]) Start(ctx context.Context) {
+	p.App().Start(ctx)
+}
+
+// Wait blocks until the program has exited and returns Run's error.
+func (p *Program[M]) Wait() error {
+	return p.App().Wait()
+}
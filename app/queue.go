@@ -0,0 +1,48 @@
+package app
+
+// maxDrain bounds how many queued messages one pass of the run loop takes
+// from its channels, so a fast producer cannot hold off the next frame.
+const maxDrain = 256
+
+// Coalescer is implemented by messages where only the latest value
+// matters, such as progress or status updates. When several queued
+// messages in a row share a non-empty CoalesceKey, Update only receives
+// the last of them.
+type Coalescer interface {
+	CoalesceKey() string
+}
+
+// coalesce merges runs of consecutive messages in place: resizes keep the
+// last size, scrolls add up and Coalescers with the same key keep the
+// latest.
+func coalesce(msgs []Msg) []Msg {
+	out := msgs[:0]
+	for _, m := range msgs {
+		if n := len(out); n > 0 {
+			if merged, ok := merge(out[n-1], m); ok {
+				out[n-1] = merged
+				continue
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func merge(prev, next Msg) (Msg, bool) {
+	switch n := next.(type) {
+	case ResizeMsg:
+		if _, ok := prev.(ResizeMsg); ok {
+			return n, true
+		}
+	case ScrollMsg:
+		if p, ok := prev.(ScrollMsg); ok {
+			return ScrollMsg{Delta: p.Delta + n.Delta}, true
+		}
+	case Coalescer:
+		if p, ok := prev.(Coalescer); ok && n.CoalesceKey() != "" && p.CoalesceKey() == n.CoalesceKey() {
+			return n, true
+		}
+	}
+	return nil, false
+}
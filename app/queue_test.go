@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stukennedy/tooey/input"
+)
+
+type progressMsg struct {
+	job  string
+	done int
+}
+
+func (m progressMsg) CoalesceKey() string { return "progress/" + m.job }
+
+func TestCoalesce(t *testing.T) {
+	key := KeyMsg{Key: input.Key{Type: input.Enter}}
+	msgs := []Msg{
+		ResizeMsg{80, 24}, ResizeMsg{100, 30},
+		ScrollMsg{Delta: 3}, ScrollMsg{Delta: 3},
+		key,
+		ScrollMsg{Delta: -3},
+		progressMsg{"a", 1}, progressMsg{"a", 2}, progressMsg{"b", 1}, progressMsg{"a", 3},
+	}
+	want := []Msg{
+		ResizeMsg{100, 30},
+		ScrollMsg{Delta: 6},
+		key,
+		ScrollMsg{Delta: -3},
+		progressMsg{"a", 2}, progressMsg{"b", 1}, progressMsg{"a", 3},
+	}
+	if got := coalesce(msgs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestInputBeforeBacklog(t *testing.T) {
+	keyCh := make(chan input.Key, 1)
+	now := time.Unix(1000, 0)
+	handled, atKey := 0, -1
+	p, keys := testProgram(func(m int, msg Msg) Result[int] {
+		switch msg.(type) {
+		case string:
+			// Each background message uses 1ms of the frame budget
+			now = now.Add(time.Millisecond)
+			handled++
+			if handled == 5 {
+				keyCh <- input.Key{Type: input.RuneKey, Rune: 'x'}
+			}
+		case KeyMsg:
+			atKey = handled
+			return Stop(m)
+		}
+		return Next(m)
+	}, Options{})
+	defer keys.Close()
+	a := p.App()
+	a.clock = func() time.Time { return now }
+	a.readKeys = func(context.Context, io.Reader) <-chan input.Key { return keyCh }
+	for range 60 {
+		p.Send("log line")
+	}
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first pass handles about a frame's worth (34ms) of the backlog;
+	// the key pressed meanwhile goes first in the next one
+	if atKey < 5 || atKey >= 60 {
+		t.Fatalf("expected the key to jump the queue, handled after %d messages", atKey)
+	}
+}
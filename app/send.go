@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrRunning is returned by Run when the app is already running.
+var ErrRunning = errors.New("app: already running")
+
+// inbox carries messages from other goroutines into a run of an App and
+// reports when that run has exited. Each run gets its own inbox.
+type inbox struct {
+	msgs    chan Msg
+	done    chan struct{}
+	err     error
+	started bool
+}
+
+func newInbox() *inbox {
+	return &inbox{msgs: make(chan Msg, 64), done: make(chan struct{})}
+}
+
+// inbox returns the inbox of the current run, or of the next one if the
+// app has not run yet.
+func (a *App) inbox() *inbox {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.box == nil {
+		a.box = newInbox()
+	}
+	return a.box
+}
+
+// begin returns the inbox for a new run, keeping messages sent before it
+// started. After a previous run has exited it starts a fresh one.
+func (a *App) begin() (*inbox, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.box != nil && a.box.started {
+		select {
+		case <-a.box.done:
+			a.box = nil
+		default:
+			return nil, ErrRunning
+		}
+	}
+	if a.box == nil {
+		a.box = newInbox()
+	}
+	a.box.started = true
+	return a.box, nil
+}
+
+// Send delivers msg to Update from any goroutine, e.g. a file watcher or
+// stream running alongside the UI. It may be called before Run; it blocks
+// while the queue is full and drops msg once the app has exited. Don't
+// call it from Update, return a Cmd instead.
+func (a *App) Send(msg Msg) {
+	in := a.inbox()
+	select {
+	case in.msgs <- msg:
+	case <-in.done:
+	}
+}
+
+// Quit asks the app to quit, as returning Quit from Update would.
+func (a *App) Quit() {
+	a.Send(QuitMsg{})
+}
+
+// Start runs the app in a new goroutine; use Wait for its result. It does
+// nothing if the app is already running.
+func (a *App) Start(ctx context.Context) {
+	if sent, err := a.begin(); err == nil {
+		go a.run(ctx, sent)
+	}
+}
+
+// Wait blocks until the app's current or last run has exited and returns
+// Run's error.
+func (a *App) Wait() error {
+	in := a.inbox()
+	<-in.done
+	return in.err
+}
@@ -0,0 +1,68 @@
+package app
+
+import "context"
+
+// Subscription is a long-running source of messages declared from the model.
+// The runtime starts it when its Key first appears in Subscriptions and
+// cancels its context when the key disappears, so everything it depends on
+// (a URL, a file path) belongs in the key. Run sends messages with send and
+// may return a final Msg; once it returns it is not restarted until its key
+// has been dropped and declared again.
+type Subscription struct {
+	Key string
+	Run func(ctx context.Context, send func(Msg)) Msg
+}
+
+// subscriptions tracks the running subscriptions by key.
+type subscriptions struct {
+	running map[string]context.CancelFunc
+}
+
+// sync starts the declared subscriptions that are not running and cancels
+// the running ones no longer declared. Messages go to out until the
+// subscription is cancelled.
+func (s *subscriptions) sync(ctx context.Context, declared []Subscription, out chan<- Msg) {
+	if s.running == nil {
+		s.running = map[string]context.CancelFunc{}
+	}
+	keep := make(map[string]bool, len(declared))
+	for _, sub := range declared {
+		if keep[sub.Key] {
+			continue // first declaration of a key wins
+		}
+		keep[sub.Key] = true
+		if _, ok := s.running[sub.Key]; ok {
+			continue
+		}
+		subCtx, cancel := context.WithCancel(ctx)
+		s.running[sub.Key] = cancel
+		go runSubscription(subCtx, sub, out)
+	}
+	for key, cancel := range s.running {
+		if !keep[key] {
+			cancel()
+			delete(s.running, key)
+		}
+	}
+}
+
+// stop cancels every running subscription.
+func (s *subscriptions) stop() {
+	for key, cancel := range s.running {
+		cancel()
+		delete(s.running, key)
+	}
+}
+
+func runSubscription(ctx context.Context, sub Subscription, out chan<- Msg) {
+	send := func(msg Msg) {
+		select {
+		case out <- msg:
+		case <-ctx.Done():
+		}
+	}
+	defer catch(send)
+	if m := sub.Run(ctx, send); m != nil && ctx.Err() == nil {
+		send(m)
+	}
+}
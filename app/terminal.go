@@ -0,0 +1,171 @@
+package app
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/stukennedy/tooey/ansi"
+	"github.com/stukennedy/tooey/input"
+
+	"golang.org/x/term"
+)
+
+// ResumeMsg is sent after the app resumes from a suspend (Ctrl+Z).
+type ResumeMsg struct{}
+
+// suspendMsg asks the runtime to suspend the process.
+type suspendMsg struct{}
+
+// Suspend is a Cmd that suspends the app as Ctrl+Z does: the terminal is
+// restored and the process stopped until the shell continues it.
+func Suspend() Msg {
+	return suspendMsg{}
+}
+
+// execRequest asks the runtime to run cmd with the terminal.
+type execRequest struct {
+	cmd    *exec.Cmd
+	onExit func(error) Msg
+}
+
+// Exec returns a Cmd that hands the terminal to cmd, for example $EDITOR,
+// and takes it back when cmd exits. onExit receives the error from
+// cmd.Run and may return a message for Update, or be nil. cmd's standard
+// streams default to the app's input and output and os.Stderr.
+func Exec(cmd *exec.Cmd, onExit func(err error) Msg) Cmd {
+	return func() Msg {
+		return &execRequest{cmd: cmd, onExit: onExit}
+	}
+}
+
+// terminal sets up and releases the terminal modes the app runs in,
+// including raw mode when the input is a terminal.
+type terminal struct {
+	out    io.Writer
+	fd     int // input file descriptor, or -1
+	state  *term.State
+	active bool
+}
+
+func newTerminal(in io.Reader, out io.Writer) *terminal {
+	t := &terminal{out: out, fd: -1}
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		t.fd = int(f.Fd())
+	}
+	return t
+}
+
+func (t *terminal) setup() error {
+	if t.fd >= 0 {
+		state, err := term.MakeRaw(t.fd)
+		if err != nil {
+			return err
+		}
+		t.state = state
+	}
+	t.active = true
+	ansi.EnterAltScreen(t.out)
+	ansi.HideCursor(t.out)
+	ansi.EnableFocusReporting(t.out)
+	ansi.EnableMouseReporting(t.out)
+	ansi.ClearScreen(t.out)
+	return nil
+}
+
+// release restores the terminal. It is safe to call when not set up.
+func (t *terminal) release() {
+	if !t.active {
+		return
+	}
+	t.active = false
+	ansi.DisableMouseReporting(t.out)
+	ansi.DisableFocusReporting(t.out)
+	ansi.ShowCursor(t.out)
+	ansi.LeaveAltScreen(t.out)
+	if t.state != nil {
+		term.Restore(t.fd, t.state)
+		t.state = nil
+	}
+}
+
+// keyReader reads keys from the app's input and can stop reading while
+// another program owns the terminal.
+type keyReader struct {
+	ctx    context.Context
+	in     io.Reader
+	r      input.CancelReader
+	cancel context.CancelFunc
+	paused bool
+	read   func(context.Context, io.Reader) <-chan input.Key
+	keys   <-chan input.Key
+}
+
+// newKeyReader reads keys from in with read, or input.ReadKeys if nil.
+func newKeyReader(ctx context.Context, in io.Reader, read func(context.Context, io.Reader) <-chan input.Key) *keyReader {
+	if read == nil {
+		read = input.ReadKeys
+	}
+	k := &keyReader{ctx: ctx, in: in, read: read}
+	k.start()
+	return k
+}
+
+func (k *keyReader) start() {
+	ctx, cancel := context.WithCancel(k.ctx)
+	k.r = input.NewCancelReader(k.in)
+	k.cancel = cancel
+	k.keys = k.read(ctx, k.r)
+}
+
+// pause stops reading. Inputs whose reads cannot be interrupted keep
+// reading.
+func (k *keyReader) pause() {
+	if k.r.Cancel() {
+		k.cancel()
+		k.keys = nil
+		k.paused = true
+	}
+}
+
+// stop stops reading for good.
+func (k *keyReader) stop() {
+	k.r.Cancel()
+	k.cancel()
+}
+
+func (k *keyReader) resume() {
+	if k.paused {
+		k.paused = false
+		k.start()
+	}
+}
+
+// suspend stops the process group as the shell's Ctrl+Z would, and
+// returns once it is continued.
+func suspend() {
+	cont := make(chan os.Signal, 1)
+	signal.Notify(cont, syscall.SIGCONT)
+	defer signal.Stop(cont)
+	if err := syscall.Kill(0, syscall.SIGTSTP); err != nil {
+		return
+	}
+	<-cont
+}
+
+// runExec runs r's command attached to the terminal.
+func runExec(r *execRequest, in io.Reader, out io.Writer) error {
+	if r.cmd.Stdin == nil {
+		r.cmd.Stdin = in
+	}
+	if r.cmd.Stdout == nil {
+		r.cmd.Stdout = out
+	}
+	if r.cmd.Stderr == nil {
+		r.cmd.Stderr = os.Stderr
+	}
+	return r.cmd.Run()
+}
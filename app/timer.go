@@ -0,0 +1,195 @@
+package app
+
+import (
+	"sort"
+	"time"
+)
+
+// timerRequest asks the runtime to deliver fn's message later. Timer
+// commands return one; Run schedules it instead of passing it to Update.
+type timerRequest struct {
+	key    string
+	d      time.Duration
+	repeat bool // fire every d, aligned to multiples of d
+	frame  bool // fire on the next frame tick
+	fn     func(time.Time) Msg
+}
+
+// cancelTimer asks the runtime to drop the timer with key.
+type cancelTimer struct {
+	key string
+}
+
+// Tick returns a Cmd that delivers fn(t) once, d from now.
+func Tick(d time.Duration, fn func(t time.Time) Msg) Cmd {
+	return func() Msg {
+		return &timerRequest{d: d, fn: fn}
+	}
+}
+
+// Every returns a Cmd that delivers fn(t) at every wall-clock multiple of d
+// (every whole second for time.Second, say) until it is cancelled. Give it a
+// key with WithKey to cancel or replace it.
+func Every(d time.Duration, fn func(t time.Time) Msg) Cmd {
+	return func() Msg {
+		return &timerRequest{d: d, repeat: true, fn: fn}
+	}
+}
+
+// FrameTick returns a Cmd that delivers fn(t) on the render loop's next
+// frame. Return it again from Update to keep an animation running.
+func FrameTick(fn func(t time.Time) Msg) Cmd {
+	return func() Msg {
+		return &timerRequest{frame: true, fn: fn}
+	}
+}
+
+// CancelTimer returns a Cmd that stops the timer started with key.
+func CancelTimer(key string) Cmd {
+	return func() Msg {
+		return cancelTimer{key: key}
+	}
+}
+
+// WithKey names a timer command: starting a timer replaces any pending one
+// with the same key, and CancelTimer(key) stops it. Other commands are
+// unaffected.
+func (c Cmd) WithKey(key string) Cmd {
+	return func() Msg {
+		m := c()
+		if r, ok := m.(*timerRequest); ok {
+			keyed := *r
+			keyed.key = key
+			return &keyed
+		}
+		return m
+	}
+}
+
+// then returns a copy of r whose first message is followed by rest, the
+// remainder of a Sequence. If the timer is cancelled or replaced first, the
+// rest never runs.
+func (r *timerRequest) then(rest []Cmd) *timerRequest {
+	next := *r
+	fired := false
+	next.fn = func(t time.Time) Msg {
+		msg := r.fn(t)
+		if fired {
+			return msg
+		}
+		fired = true
+		if msg == nil {
+			return sequenceNext{rest: rest}
+		}
+		return &sequenceStep{msg: msg, rest: rest}
+	}
+	return &next
+}
+
+type timer struct {
+	req *timerRequest
+	at  time.Time
+}
+
+// timers holds the runtime's pending timers, all driven from the run loop by
+// a single time.Timer.
+type timers struct {
+	pending []timer // sorted by at
+	frames  []*timerRequest
+}
+
+func (ts *timers) add(r *timerRequest, now time.Time) {
+	if r.key != "" {
+		ts.cancel(r.key)
+	}
+	if r.frame {
+		ts.frames = append(ts.frames, r)
+		return
+	}
+	ts.schedule(timer{req: r, at: nextFire(r, now)})
+}
+
+func (ts *timers) schedule(t timer) {
+	i := sort.Search(len(ts.pending), func(i int) bool { return ts.pending[i].at.After(t.at) })
+	ts.pending = append(ts.pending, timer{})
+	copy(ts.pending[i+1:], ts.pending[i:])
+	ts.pending[i] = t
+}
+
+func (ts *timers) cancel(key string) {
+	kept := ts.pending[:0]
+	for _, t := range ts.pending {
+		if t.req.key != key {
+			kept = append(kept, t)
+		}
+	}
+	ts.pending = kept
+
+	frames := ts.frames[:0]
+	for _, r := range ts.frames {
+		if r.key != key {
+			frames = append(frames, r)
+		}
+	}
+	ts.frames = frames
+}
+
+// due returns the messages of every timer due at now, rescheduling the
+// repeating ones.
+func (ts *timers) due(now time.Time) []Msg {
+	var msgs []Msg
+	for len(ts.pending) > 0 && !ts.pending[0].at.After(now) {
+		t := ts.pending[0]
+		ts.pending = ts.pending[1:]
+		if m := t.req.fn(t.at); m != nil {
+			msgs = append(msgs, m)
+		}
+		if t.req.repeat && t.req.d > 0 {
+			ts.schedule(timer{req: t.req, at: nextFire(t.req, now)})
+		}
+	}
+	return msgs
+}
+
+// frame returns the messages of the frame tick requests.
+func (ts *timers) frame(now time.Time) []Msg {
+	var msgs []Msg
+	for _, r := range ts.frames {
+		if m := r.fn(now); m != nil {
+			msgs = append(msgs, m)
+		}
+	}
+	ts.frames = ts.frames[:0]
+	return msgs
+}
+
+// hasFrames reports whether a frame tick has been requested.
+func (ts *timers) hasFrames() bool {
+	return len(ts.frames) > 0
+}
+
+// next returns when the earliest timer is due.
+func (ts *timers) next() (time.Time, bool) {
+	if len(ts.pending) == 0 {
+		return time.Time{}, false
+	}
+	return ts.pending[0].at, true
+}
+
+// reset points t at the earliest pending timer, or stops it.
+func (ts *timers) reset(t *time.Timer, now time.Time) {
+	at, ok := ts.next()
+	if !ok {
+		t.Stop()
+		return
+	}
+	t.Reset(max(at.Sub(now), 0))
+}
+
+// nextFire returns when a timer requested at now fires next.
+func nextFire(r *timerRequest, now time.Time) time.Time {
+	if r.repeat && r.d > 0 {
+		return now.Truncate(r.d).Add(r.d)
+	}
+	return now.Add(r.d)
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func request(c Cmd) *timerRequest {
+	return c().(*timerRequest)
+}
+
+func TestTimersTickOnce(t *testing.T) {
+	var ts timers
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ts.add(request(Tick(time.Second, func(time.Time) Msg { return "tick" })), now)
+
+	if msgs := ts.due(now.Add(500 * time.Millisecond)); len(msgs) != 0 {
+		t.Fatalf("expected nothing before the deadline, got %v", msgs)
+	}
+	if msgs := ts.due(now.Add(time.Second)); len(msgs) != 1 || msgs[0] != "tick" {
+		t.Fatalf("expected one tick, got %v", msgs)
+	}
+	if _, ok := ts.next(); ok {
+		t.Fatal("expected a one-shot tick not to be rescheduled")
+	}
+}
+
+func TestTimersEveryAligned(t *testing.T) {
+	var ts timers
+	now := time.Date(2024, 1, 1, 12, 0, 0, 300*int(time.Millisecond), time.UTC)
+	ts.add(request(Every(time.Second, func(at time.Time) Msg { return at })), now)
+
+	at, _ := ts.next()
+	if want := now.Truncate(time.Second).Add(time.Second); !at.Equal(want) {
+		t.Fatalf("expected first fire at %v, got %v", want, at)
+	}
+	msgs := ts.due(at.Add(10 * time.Millisecond))
+	if len(msgs) != 1 || !msgs[0].(time.Time).Equal(at) {
+		t.Fatalf("expected the boundary time, got %v", msgs)
+	}
+	if next, _ := ts.next(); !next.Equal(at.Add(time.Second)) {
+		t.Fatalf("expected the next boundary, got %v", next)
+	}
+}
+
+func TestTimersKeyedReplaceAndCancel(t *testing.T) {
+	var ts timers
+	now := time.Now()
+	tick := func(s string) Cmd { return Tick(time.Second, func(time.Time) Msg { return s }) }
+	ts.add(request(tick("a").WithKey("k")), now)
+	ts.add(request(tick("b").WithKey("k")), now)
+	ts.add(request(tick("c")), now)
+
+	msgs := ts.due(now.Add(time.Second))
+	if len(msgs) != 2 || msgs[0] != "b" || msgs[1] != "c" {
+		t.Fatalf("expected the replacement and the unkeyed tick, got %v", msgs)
+	}
+
+	ts.add(request(Every(time.Second, func(time.Time) Msg { return "x" }).WithKey("clock")), now)
+	ts.cancel(CancelTimer("clock")().(cancelTimer).key)
+	if _, ok := ts.next(); ok {
+		t.Fatal("expected the cancelled timer to be gone")
+	}
+}
+
+func TestTimersFrameTick(t *testing.T) {
+	var ts timers
+	ts.add(request(FrameTick(func(time.Time) Msg { return "frame" })), time.Now())
+	if msgs := ts.frame(time.Now()); len(msgs) != 1 {
+		t.Fatalf("expected one frame message, got %v", msgs)
+	}
+	if msgs := ts.frame(time.Now()); len(msgs) != 0 {
+		t.Fatalf("expected frame ticks to be one-shot, got %v", msgs)
+	}
+}
+
+func TestWithKeyLeavesOtherCmds(t *testing.T) {
+	c := Cmd(func() Msg { return "plain" }).WithKey("k")
+	if c() != "plain" {
+		t.Fatal("expected non-timer command to pass through")
+	}
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"time"
+
+	"github.com/stukennedy/tooey/ansi"
+	"github.com/stukennedy/tooey/node"
+)
+
+// ViewContext is what a view knows about the frame being drawn besides the
+// model: the terminal size, its color support, the focused key, when the
+// frame started and the app's theme.
+type ViewContext struct {
+	Width, Height int
+	Colors        ansi.ColorProfile
+	Focused       string
+	Time          time.Time
+	Theme         Theme
+}
+
+// Theme is a small palette views can draw from instead of hardcoding colors.
+// Colors are ANSI palette indexes; 0 means the terminal default.
+type Theme struct {
+	FG, BG      node.Color // body text
+	Accent      node.Color // titles, highlights, the focused element
+	Muted       node.Color // secondary text, hints
+	Border      node.Color // box borders and separators
+	SelectionFG node.Color // selected row text
+	SelectionBG node.Color // selected row background
+	Success     node.Color
+	Warning     node.Color
+	Error       node.Color
+}
+
+// DefaultTheme returns a theme built from the 16 basic colors, so it works
+// on any color terminal.
+func DefaultTheme() Theme {
+	return Theme{
+		Accent:      6, // cyan
+		Muted:       8, // bright black
+		Border:      8, // bright black
+		SelectionBG: 4, // blue
+		Success:     2, // green
+		Warning:     3, // yellow
+		Error:       1, // red
+	}
+}
+
+// view renders the model with ViewCtx if set, falling back to View.
+func (a *App) view(model interface{}, ctx ViewContext) node.Node {
+	if a.ViewCtx != nil {
+		return a.ViewCtx(model, ctx)
+	}
+	return a.View(model, ctx.Focused)
+}
@@ -8,6 +8,7 @@ type Cell struct {
 	FG    node.Color
 	BG    node.Color
 	Style node.StyleFlags
+	Link  string // hyperlink target, "" for none
 }
 
 // Buffer is a row-major flat cell buffer representing a terminal frame.
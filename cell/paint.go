@@ -1,15 +1,60 @@
 package cell
 
 import (
-	"strings"
-
 	"github.com/stukennedy/tooey/layout"
 	"github.com/stukennedy/tooey/node"
 )
 
+// shadowBG is the background color used for layer drop shadows.
+const shadowBG node.Color = 235
+
 // Paint renders a layout tree into the cell buffer.
+// Layers are painted after the main tree, in the order layout resolved them.
 func Paint(buf *Buffer, tree layout.LayoutNode) {
 	paintNode(buf, tree, tree.Rect)
+	for _, l := range tree.Layers {
+		paintLayer(buf, l, tree.Rect)
+	}
+}
+
+func paintLayer(buf *Buffer, ln layout.LayoutNode, screen layout.Rect) {
+	p := ln.Node.Props
+	if p.Backdrop {
+		for y := screen.Y; y < screen.Y+screen.H; y++ {
+			for x := screen.X; x < screen.X+screen.W; x++ {
+				c := buf.Get(x, y)
+				c.Style |= node.Dim
+				buf.Set(x, y, c)
+			}
+		}
+	}
+
+	r := ln.Rect
+	if p.Shadow {
+		shade := func(x, y int) {
+			if x >= screen.X && x < screen.X+screen.W && y >= screen.Y && y < screen.Y+screen.H {
+				c := buf.Get(x, y)
+				c.BG = shadowBG
+				c.Style |= node.Dim
+				buf.Set(x, y, c)
+			}
+		}
+		for y := r.Y + 1; y <= r.Y+r.H; y++ {
+			shade(r.X+r.W, y)
+		}
+		for x := r.X + 1; x < r.X+r.W; x++ {
+			shade(x, r.Y+r.H)
+		}
+	}
+
+	// Clear the layer's area so content beneath does not show through
+	clip := intersect(r, screen)
+	for y := clip.Y; y < clip.Y+clip.H; y++ {
+		for x := clip.X; x < clip.X+clip.W; x++ {
+			buf.Set(x, y, Cell{Rune: ' ', FG: p.FG, BG: p.BG})
+		}
+	}
+	paintNode(buf, ln, clip)
 }
 
 func paintNode(buf *Buffer, ln layout.LayoutNode, clip layout.Rect) {
@@ -18,7 +63,7 @@ func paintNode(buf *Buffer, ln layout.LayoutNode, clip layout.Rect) {
 
 	switch n.Type {
 	case node.TextNode:
-		paintText(buf, n, r, clip)
+		paintText(buf, ln, clip)
 	case node.BoxNode:
 		paintBox(buf, n, r, clip)
 	}
@@ -28,9 +73,48 @@ func paintNode(buf *Buffer, ln layout.LayoutNode, clip layout.Rect) {
 	for _, child := range ln.Children {
 		paintNode(buf, child, childClip)
 	}
+
+	if n.Props.Scrollbar && ln.Scroll != nil {
+		paintScrollbar(buf, ln, childClip)
+	}
+}
+
+// paintScrollbar draws a vertical scrollbar in the gutter layout reserved on
+// the right edge. The thumb is only drawn when the content overflows.
+func paintScrollbar(buf *Buffer, ln layout.LayoutNode, clip layout.Rect) {
+	r, m := ln.Rect, *ln.Scroll
+	if r.W < 1 || r.H < 1 {
+		return
+	}
+	x := r.X + r.W - 1
+	if x < clip.X || x >= clip.X+clip.W {
+		return
+	}
+
+	thumbTop, thumbH := 0, 0
+	if m.Overflows() {
+		thumbH = max(1, r.H*m.ViewportH/m.ContentH)
+		thumbTop = m.OffsetY * (r.H - thumbH) / m.MaxOffsetY()
+	}
+
+	fg, bg := ln.Node.Props.FG, ln.Node.Props.BG
+	for row := 0; row < r.H; row++ {
+		y := r.Y + row
+		if y < clip.Y || y >= clip.Y+clip.H {
+			continue
+		}
+		c := Cell{Rune: '│', FG: fg, BG: bg, Style: node.Dim}
+		if row >= thumbTop && row < thumbTop+thumbH {
+			c = Cell{Rune: '┃', FG: fg, BG: bg}
+		}
+		buf.Set(x, y, c)
+	}
 }
 
-func paintText(buf *Buffer, n node.Node, r layout.Rect, clip layout.Rect) {
+func paintText(buf *Buffer, ln layout.LayoutNode, clip layout.Rect) {
+	n, r := ln.Node, ln.Rect
+	clip = intersect(r, clip)
+
 	// First, if BG is set, fill the rect so background shows for spaces
 	if n.Props.BG != 0 {
 		for y := r.Y; y < r.Y+r.H && y < clip.Y+clip.H; y++ {
@@ -45,48 +129,113 @@ func paintText(buf *Buffer, n node.Node, r layout.Rect, clip layout.Rect) {
 		}
 	}
 
-	lines := wrapText(n.Props.Text, r.W)
+	// Layout already wrapped the text; trees built by hand may not carry lines
+	lines := ln.Lines
+	if lines == nil {
+		lines = layout.TextLines(n, r.W)
+	}
+
+	// Horizontal scroll pans the lines by the offset layout applied
+	scrollX := 0
+	if ln.Scroll != nil {
+		scrollX = ln.Scroll.OffsetX
+	}
+
+	spanAt := spanIndex(n.Props.Spans)
 	for row, line := range lines {
 		y := r.Y + row
 		if y < clip.Y || y >= clip.Y+clip.H {
 			continue
 		}
-		col := r.X
-		for _, ch := range line {
+		col := r.X - scrollX + alignShift(n.Props.TextAlign, r.W-line.Width())
+		span := -1
+		for i, ch := range line.Runes {
 			if col >= clip.X+clip.W {
 				break
 			}
+			// Inserted runes (src -1) take the style of the rune before them
+			if src := line.Src[i]; src >= 0 && src < len(spanAt) {
+				span = spanAt[src]
+			} else if span < 0 && i+1 < len(line.Src) && line.Src[i+1] >= 0 && line.Src[i+1] < len(spanAt) {
+				span = spanAt[line.Src[i+1]]
+			}
 			if col >= clip.X {
-				buf.Set(col, y, Cell{
+				c := Cell{
 					Rune:  ch,
 					FG:    n.Props.FG,
 					BG:    n.Props.BG,
 					Style: n.Props.Style,
-				})
+				}
+				if span >= 0 {
+					sp := n.Props.Spans[span]
+					if sp.FG != 0 {
+						c.FG = sp.FG
+					}
+					if sp.BG != 0 {
+						c.BG = sp.BG
+					}
+					c.Style |= sp.Style
+					c.Link = sp.Link
+				}
+				buf.Set(col, y, c)
 			}
 			col++
 		}
 	}
 }
 
+// spanIndex maps each rune of the spans' concatenated text to its span.
+func spanIndex(spans []node.Span) []int {
+	if len(spans) == 0 {
+		return nil
+	}
+	var idx []int
+	for i, sp := range spans {
+		for range sp.Text {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// alignShift returns the column offset for a line leaving free cells.
+func alignShift(a node.TextAlign, free int) int {
+	if free <= 0 {
+		return 0
+	}
+	switch a {
+	case node.TextAlignCenter:
+		return free / 2
+	case node.TextAlignRight:
+		return free
+	}
+	return 0
+}
+
 func paintBox(buf *Buffer, n node.Node, r layout.Rect, clip layout.Rect) {
-	if r.W < 2 || r.H < 2 {
+	if r.W < 1 || r.H < 1 {
 		return
 	}
 
-	var tl, tr, bl, br, hz, vt rune
-	switch n.Props.Border {
-	case node.BorderSingle:
-		tl, tr, bl, br, hz, vt = '┌', '┐', '└', '┘', '─', '│'
-	case node.BorderDouble:
-		tl, tr, bl, br, hz, vt = '╔', '╗', '╚', '╝', '═', '║'
-	case node.BorderRounded:
-		tl, tr, bl, br, hz, vt = '╭', '╮', '╰', '╯', '─', '│'
-	default:
+	p := n.Props
+	runes := p.Border.Runes()
+	if p.Border == node.BorderCustom {
+		runes = p.BorderRunes
+	}
+	if runes == (node.BorderRunes{}) {
 		return
 	}
 
-	fg, bg, style := n.Props.FG, n.Props.BG, n.Props.Style
+	fg, bg, style := p.FG, p.BG, p.Style
+	if p.BorderFG != 0 {
+		fg = p.BorderFG
+	}
+	if p.BorderBG != 0 {
+		bg = p.BorderBG
+	}
+	if p.BorderAttr != 0 {
+		style = p.BorderAttr
+	}
 
 	setClipped := func(x, y int, ch rune) {
 		if x >= clip.X && x < clip.X+clip.W && y >= clip.Y && y < clip.Y+clip.H {
@@ -94,22 +243,72 @@ func paintBox(buf *Buffer, n node.Node, r layout.Rect, clip layout.Rect) {
 		}
 	}
 
-	// Corners
-	setClipped(r.X, r.Y, tl)
-	setClipped(r.X+r.W-1, r.Y, tr)
-	setClipped(r.X, r.Y+r.H-1, bl)
-	setClipped(r.X+r.W-1, r.Y+r.H-1, br)
+	top, right := p.Sides.Has(node.SideTop), p.Sides.Has(node.SideRight)
+	bottom, left := p.Sides.Has(node.SideBottom), p.Sides.Has(node.SideLeft)
+	x2, y2 := r.X+r.W-1, r.Y+r.H-1
+
+	// Corners join two sides; with only one of them the edge runs through
+	corner := func(x, y int, horiz, vert bool, ch rune) {
+		switch {
+		case horiz && vert:
+			setClipped(x, y, ch)
+		case horiz:
+			setClipped(x, y, runes.Horizontal)
+		case vert:
+			setClipped(x, y, runes.Vertical)
+		}
+	}
+	corner(r.X, r.Y, top, left, runes.TopLeft)
+	corner(x2, r.Y, top, right, runes.TopRight)
+	corner(r.X, y2, bottom, left, runes.BottomLeft)
+	corner(x2, y2, bottom, right, runes.BottomRight)
 
 	// Horizontal edges
-	for x := r.X + 1; x < r.X+r.W-1; x++ {
-		setClipped(x, r.Y, hz)
-		setClipped(x, r.Y+r.H-1, hz)
+	for x := r.X + 1; x < x2; x++ {
+		if top {
+			setClipped(x, r.Y, runes.Horizontal)
+		}
+		if bottom {
+			setClipped(x, y2, runes.Horizontal)
+		}
 	}
 
 	// Vertical edges
-	for y := r.Y + 1; y < r.Y+r.H-1; y++ {
-		setClipped(r.X, y, vt)
-		setClipped(r.X+r.W-1, y, vt)
+	for y := r.Y + 1; y < y2; y++ {
+		if left {
+			setClipped(r.X, y, runes.Vertical)
+		}
+		if right {
+			setClipped(x2, y, runes.Vertical)
+		}
+	}
+
+	if top && p.Title != "" {
+		paintLabel(buf, p.Title, p.TitleAlign, r, r.Y, clip, Cell{FG: fg, BG: bg, Style: style})
+	}
+	if bottom && p.Footer != "" {
+		paintLabel(buf, p.Footer, p.FooterAlign, r, y2, clip, Cell{FG: fg, BG: bg, Style: style})
+	}
+}
+
+// paintLabel writes a title or footer into the border row y of r, padded
+// with a space and kept clear of the corners by at least one border rune.
+// Labels too long for the border are truncated with an ellipsis.
+func paintLabel(buf *Buffer, label string, align node.TextAlign, r layout.Rect, y int, clip layout.Rect, style Cell) {
+	span := r.W - 2 // between the corners
+	text := []rune(node.Truncate(label, span-4))
+	if len(text) == 0 || y < clip.Y || y >= clip.Y+clip.H {
+		return
+	}
+	text = append(append([]rune{' '}, text...), ' ')
+	x := r.X + 2 + alignShift(align, span-2-len(text))
+	for _, ch := range text {
+		if x >= clip.X && x < clip.X+clip.W {
+			c := style
+			c.Rune = ch
+			buf.Set(x, y, c)
+		}
+		x++
 	}
 }
 
@@ -123,57 +322,3 @@ func intersect(a, b layout.Rect) layout.Rect {
 	}
 	return layout.Rect{X: x1, Y: y1, W: x2 - x1, H: y2 - y1}
 }
-
-// wrapText splits text into lines that fit within maxWidth, preserving leading whitespace.
-func wrapText(s string, maxWidth int) []string {
-	if maxWidth <= 0 {
-		return nil
-	}
-	if s == "" {
-		return []string{""}
-	}
-
-	// Split on existing newlines first
-	rawLines := strings.Split(s, "\n")
-	var result []string
-	for _, raw := range rawLines {
-		// Preserve leading whitespace
-		trimmed := strings.TrimLeft(raw, " \t")
-		leading := raw[:len(raw)-len(trimmed)]
-
-		if trimmed == "" {
-			result = append(result, leading)
-			continue
-		}
-
-		words := strings.Fields(trimmed)
-		if len(words) == 0 {
-			result = append(result, leading)
-			continue
-		}
-
-		line := leading + words[0]
-		lineLen := runeCount(line)
-		for _, w := range words[1:] {
-			wLen := runeCount(w)
-			if lineLen+1+wLen <= maxWidth {
-				line += " " + w
-				lineLen += 1 + wLen
-			} else {
-				result = append(result, line)
-				line = leading + w
-				lineLen = runeCount(leading) + wLen
-			}
-		}
-		result = append(result, line)
-	}
-	return result
-}
-
-func runeCount(s string) int {
-	n := 0
-	for range s {
-		n++
-	}
-	return n
-}
@@ -59,3 +59,177 @@ func TestPaintColumn(t *testing.T) {
 		t.Fatalf("expected 'b' at (0,1), got %c", buf.Get(0, 1).Rune)
 	}
 }
+
+func TestPaintTextAlign(t *testing.T) {
+	tree := node.Text("12:00").WithTextAlign(node.TextAlignRight)
+	lt := layout.Layout(tree, 10, 1)
+	buf := NewBuffer(10, 1)
+	Paint(buf, lt)
+
+	if buf.Get(5, 0).Rune != '1' || buf.Get(9, 0).Rune != '0' {
+		t.Fatalf("expected right-aligned text, got %c at 5 and %c at 9", buf.Get(5, 0).Rune, buf.Get(9, 0).Rune)
+	}
+
+	tree = node.Text("ab").WithTextAlign(node.TextAlignCenter)
+	lt = layout.Layout(tree, 6, 1)
+	buf = NewBuffer(6, 1)
+	Paint(buf, lt)
+	if buf.Get(2, 0).Rune != 'a' {
+		t.Fatalf("expected centered text at x=2, got %c", buf.Get(2, 0).Rune)
+	}
+}
+
+func TestPaintLayerOverContent(t *testing.T) {
+	tree := node.Column(
+		node.Text("xxxxxxxxxx"),
+		node.Text("xxxxxxxxxx"),
+		node.Text("xxxxxxxxxx"),
+		node.Modal(node.Text("ok")).WithSize(4, 1),
+	)
+	lt := layout.Layout(tree, 10, 3)
+	buf := NewBuffer(10, 3)
+	Paint(buf, lt)
+
+	// Layer at (3,1): "ok" then cleared cells
+	if buf.Get(3, 1).Rune != 'o' || buf.Get(4, 1).Rune != 'k' || buf.Get(5, 1).Rune != ' ' {
+		t.Fatalf("expected layer content, got %c%c%c", buf.Get(3, 1).Rune, buf.Get(4, 1).Rune, buf.Get(5, 1).Rune)
+	}
+	if buf.Get(3, 1).Style&node.Dim != 0 {
+		t.Fatal("layer content should not be dimmed")
+	}
+	if buf.Get(0, 0).Style&node.Dim == 0 {
+		t.Fatal("expected backdrop to dim content beneath the layer")
+	}
+}
+
+func TestPaintNoWrapScrollX(t *testing.T) {
+	tree := node.Text("0123456789").WithNoWrap().WithScrollX(4)
+	lt := layout.Layout(tree, 5, 2)
+	buf := NewBuffer(5, 2)
+	Paint(buf, lt)
+
+	got := ""
+	for x := 0; x < 5; x++ {
+		got += string(buf.Get(x, 0).Rune)
+	}
+	if got != "45678" {
+		t.Fatalf("expected panned window %q, got %q", "45678", got)
+	}
+	if buf.Get(0, 1).Rune != ' ' {
+		t.Fatal("no-wrap text should not spill onto a second line")
+	}
+}
+
+func TestPaintMiddleEllipsis(t *testing.T) {
+	tree := node.Text("internal/pkg/file.go").WithOverflow(node.OverflowEllipsisMiddle)
+	lt := layout.Layout(tree, 9, 1)
+	buf := NewBuffer(9, 1)
+	Paint(buf, lt)
+
+	got := ""
+	for x := 0; x < 9; x++ {
+		got += string(buf.Get(x, 0).Rune)
+	}
+	if got != "inte…e.go" {
+		t.Fatalf("expected %q, got %q", "inte…e.go", got)
+	}
+}
+
+func TestPaintRichTextWrapsSpans(t *testing.T) {
+	tree := node.RichText(
+		node.Span{Text: "plain "},
+		node.Span{Text: "bold words", Style: node.Bold, Link: "http://x"},
+		node.Span{Text: " end", FG: 2},
+	)
+	lt := layout.Layout(tree, 10, 3)
+	buf := NewBuffer(10, 3)
+	Paint(buf, lt)
+
+	// "plain bold" / "words end"
+	if buf.Get(0, 0).Style&node.Bold != 0 {
+		t.Fatal("plain span should not be bold")
+	}
+	if c := buf.Get(6, 0); c.Rune != 'b' || c.Style&node.Bold == 0 || c.Link != "http://x" {
+		t.Fatalf("expected bold linked 'b' at (6,0), got %+v", c)
+	}
+	if c := buf.Get(0, 1); c.Rune != 'w' || c.Style&node.Bold == 0 {
+		t.Fatalf("expected bold to continue onto the next line, got %+v", c)
+	}
+	if c := buf.Get(6, 1); c.Rune != 'e' || c.FG != 2 || c.Style&node.Bold != 0 {
+		t.Fatalf("expected plain green 'e' at (6,1), got %+v", c)
+	}
+}
+
+func TestPaintScrollbar(t *testing.T) {
+	var lines []node.Node
+	for i := 0; i < 8; i++ {
+		lines = append(lines, node.Text("line"))
+	}
+	tree := node.Column(lines...).WithScrollbar().WithScrollOffset(4)
+	lt := layout.Layout(tree, 6, 4)
+	buf := NewBuffer(6, 4)
+	Paint(buf, lt)
+
+	// 4 of 8 lines visible, scrolled to the end: thumb fills the bottom half
+	var bar string
+	for y := 0; y < 4; y++ {
+		bar += string(buf.Get(5, y).Rune)
+	}
+	if bar != "││┃┃" {
+		t.Fatalf("expected scrollbar ││┃┃, got %q", bar)
+	}
+	if buf.Get(4, 0).Rune != ' ' {
+		t.Fatalf("expected content to stop before the gutter, got %c", buf.Get(4, 0).Rune)
+	}
+}
+
+func rowString(buf *Buffer, y int) string {
+	var s []rune
+	for x := 0; x < buf.Width; x++ {
+		s = append(s, buf.Get(x, y).Rune)
+	}
+	return string(s)
+}
+
+func TestPaintBoxTitleAndFooter(t *testing.T) {
+	tree := node.Box(node.BorderRounded, node.Text("hi")).
+		WithTitle("Logs", node.TextAlignLeft).
+		WithFooter("3/9", node.TextAlignRight).
+		WithBorderColor(33, 0)
+	lt := layout.Layout(tree, 14, 3)
+	buf := NewBuffer(14, 3)
+	Paint(buf, lt)
+
+	if got := rowString(buf, 0); got != "╭─ Logs ─────╮" {
+		t.Fatalf("unexpected top border %q", got)
+	}
+	if got := rowString(buf, 2); got != "╰────── 3/9 ─╯" {
+		t.Fatalf("unexpected bottom border %q", got)
+	}
+	if buf.Get(3, 0).FG != 33 || buf.Get(1, 1).FG != 0 {
+		t.Fatal("expected border color on the title but not the content")
+	}
+}
+
+func TestPaintBoxTitleTruncated(t *testing.T) {
+	tree := node.Box(node.BorderSingle, node.Text("")).WithTitle("a long title", node.TextAlignCenter)
+	lt := layout.Layout(tree, 10, 2)
+	buf := NewBuffer(10, 2)
+	Paint(buf, lt)
+	if got := rowString(buf, 0); got != "┌─ a l… ─┐" {
+		t.Fatalf("unexpected top border %q", got)
+	}
+}
+
+func TestPaintBoxSidesAndRunes(t *testing.T) {
+	tree := node.Box(node.BorderASCII, node.Text("x")).WithSides(node.SideTop | node.SideBottom)
+	lt := layout.Layout(tree, 5, 3)
+	if got := lt.Children[0].Rect; got != (layout.Rect{X: 0, Y: 1, W: 5, H: 1}) {
+		t.Fatalf("expected content to use the full width, got %+v", got)
+	}
+	buf := NewBuffer(5, 3)
+	Paint(buf, lt)
+	if rowString(buf, 0) != "-----" || rowString(buf, 1) != "x    " {
+		t.Fatalf("unexpected box %q / %q", rowString(buf, 0), rowString(buf, 1))
+	}
+}
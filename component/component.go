@@ -49,13 +49,39 @@ func (l List) Render(focused string) node.Node {
 	return node.Column(children...)
 }
 
-// Box renders a bordered box with a title.
+// Box renders a bordered panel with a title and footer in its border. When
+// Focused, it switches to FocusBorder (BorderThick if unset) and FocusFG so
+// the active panel stands out.
 type Box struct {
-	Title  string
-	Border node.BorderStyle
+	Key         string
+	Title       string
+	TitleAlign  node.TextAlign
+	Footer      string
+	FooterAlign node.TextAlign
+	Border      node.BorderStyle
+	BorderFG    node.Color
+	Focused     bool
+	FocusBorder node.BorderStyle
+	FocusFG     node.Color
 }
 
 func (b Box) Render(child node.Node) node.Node {
-	// For now, just wrap in a box node. Title rendering is handled at paint level.
-	return node.Box(b.Border, child)
+	border, fg := b.Border, b.BorderFG
+	if b.Focused {
+		border = b.FocusBorder
+		if border == node.BorderNone {
+			border = node.BorderThick
+		}
+		if b.FocusFG != 0 {
+			fg = b.FocusFG
+		}
+	}
+	n := node.Box(border, child).
+		WithTitle(b.Title, b.TitleAlign).
+		WithFooter(b.Footer, b.FooterAlign).
+		WithBorderColor(fg, 0)
+	if b.Key != "" {
+		n = n.WithKey(b.Key)
+	}
+	return n
 }
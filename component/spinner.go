@@ -37,13 +37,25 @@ func Spinner(label string, frameIdx int, style SpinnerStyle, fg node.Color) node
 	)
 }
 
-// SpinnerTickMsg is sent when a spinner tick fires.
-type SpinnerTickMsg struct{}
+// SpinnerTickMsg is sent when a spinner tick fires. ID is the spinner
+// passed to SpinnerTickFor, or "" for SpinnerTick.
+type SpinnerTickMsg struct {
+	ID string
+}
 
-// SpinnerTick returns a Cmd that sends a SpinnerTickMsg after the given interval.
+// SpinnerTick returns a Cmd that sends a SpinnerTickMsg after the given
+// interval. Each call starts its own timer.
 func SpinnerTick(interval time.Duration) app.Cmd {
-	return func() app.Msg {
-		time.Sleep(interval)
+	return app.Tick(interval, func(time.Time) app.Msg {
 		return SpinnerTickMsg{}
-	}
+	})
+}
+
+// SpinnerTickFor returns a Cmd that sends a SpinnerTickMsg with ID id after
+// the given interval. The timer is keyed by id, so re-arming one spinner
+// replaces only its own pending tick and other spinners keep running.
+func SpinnerTickFor(id string, interval time.Duration) app.Cmd {
+	return app.Tick(interval, func(time.Time) app.Msg {
+		return SpinnerTickMsg{ID: id}
+	}).WithKey("tooey.spinner/" + id)
 }
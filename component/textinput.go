@@ -90,11 +90,11 @@ func (ti TextInput) Render(prefix string, fg, bg node.Color, width int) node.Nod
 	if ti.Value == "" {
 		// Show cursor block + placeholder when focused and empty
 		if ti.Focused {
-			return node.Row(
-				node.TextStyled(prefix, fg, bg, 0),
-				node.TextStyled(" ", node.Color(0), node.Color(15), 0), // block cursor
-				node.TextStyled(ti.Placeholder, node.Color(8), bg, node.Dim),
-			)
+			return node.RichText(
+				node.Span{Text: prefix, FG: fg, BG: bg},
+				node.Span{Text: " ", FG: node.Color(0), BG: node.Color(15)}, // block cursor
+				node.Span{Text: ti.Placeholder, FG: node.Color(8), BG: bg, Style: node.Dim},
+			).WithOverflow(node.OverflowWrapChar)
 		}
 		return node.TextStyled(prefix+ti.Placeholder, node.Color(8), bg, node.Dim)
 	}
@@ -161,11 +161,12 @@ func (ti TextInput) Render(prefix string, fg, bg node.Color, width int) node.Nod
 			} else {
 				cursorChar = " "
 			}
-			ln = node.Row(
-				node.TextStyled(linePrefix+before, fg, bg, 0),
-				node.TextStyled(cursorChar, node.Color(0), node.Color(15), 0),
-				node.TextStyled(after, fg, bg, 0),
-			)
+			// Character wrapping keeps every space, so the cursor stays put
+			ln = node.RichText(
+				node.Span{Text: linePrefix + before, FG: fg, BG: bg},
+				node.Span{Text: cursorChar, FG: node.Color(0), BG: node.Color(15)},
+				node.Span{Text: after, FG: fg, BG: bg},
+			).WithOverflow(node.OverflowWrapChar)
 		} else {
 			ln = node.TextStyled(linePrefix+dl.text, fg, bg, 0)
 		}
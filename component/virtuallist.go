@@ -0,0 +1,70 @@
+package component
+
+import (
+	"github.com/stukennedy/tooey/input"
+	"github.com/stukennedy/tooey/layout"
+	"github.com/stukennedy/tooey/node"
+)
+
+// VirtualList is a selectable list over Count rows that only renders the
+// rows in view, for collections too large to build a node per item.
+// Feed it keys with Update and its app.ScrollMetricsMsg metrics with Sync so
+// paging follows the real viewport.
+type VirtualList struct {
+	Key       string
+	Count     int
+	Selected  int
+	Offset    int // index of the first visible row, as last laid out
+	Page      int // rows per page, as last laid out
+	Overscan  int
+	Scrollbar bool
+}
+
+// NewVirtualList creates a list of count rows with the first one selected.
+func NewVirtualList(key string, count int) VirtualList {
+	return VirtualList{Key: key, Count: count, Overscan: 2}
+}
+
+// Update moves the selection for Up/Down, PageUp/PageDown and Home/End.
+func (l VirtualList) Update(key input.Key) VirtualList {
+	page := max(l.Page-1, 1)
+	switch key.Type {
+	case input.Up:
+		l.Selected--
+	case input.Down:
+		l.Selected++
+	case input.PageUp:
+		l.Selected -= page
+		l.Offset -= page
+	case input.PageDown:
+		l.Selected += page
+		l.Offset += page
+	case input.Home:
+		l.Selected = 0
+	case input.End:
+		l.Selected = l.Count - 1
+	}
+	l.Selected = max(0, min(l.Selected, l.Count-1))
+	l.Offset = max(0, l.Offset)
+	return l
+}
+
+// Sync records the offset and page size layout settled on.
+func (l VirtualList) Sync(m layout.ScrollMetrics) VirtualList {
+	l.Offset = m.OffsetY
+	l.Page = m.ViewportH
+	return l
+}
+
+// Render returns the list node. row builds row i and is only called for
+// rows in or near the viewport.
+func (l VirtualList) Render(row func(i int, selected bool) node.Node) node.Node {
+	sel := l.Selected
+	n := node.VirtualList(l.Count, func(i int) node.Node {
+		return row(i, i == sel)
+	}).WithKey(l.Key).WithScrollOffset(l.Offset).WithSelected(sel).WithOverscan(l.Overscan)
+	if l.Scrollbar {
+		n = n.WithScrollbar()
+	}
+	return n
+}
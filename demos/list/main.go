@@ -8,8 +8,6 @@ import (
 	"github.com/stukennedy/tooey/app"
 	"github.com/stukennedy/tooey/input"
 	"github.com/stukennedy/tooey/node"
-
-	"golang.org/x/term"
 )
 
 type model struct {
@@ -19,23 +17,15 @@ type model struct {
 }
 
 func main() {
-	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to set raw mode: %v\n", err)
-		os.Exit(1)
-	}
-	defer term.Restore(int(os.Stdin.Fd()), oldState)
-
-	a := &app.App{
-		Init: func() interface{} {
+	p := &app.Program[*model]{
+		Init: func() *model {
 			return &model{
 				items:    []string{"Alpha", "Beta", "Gamma", "Delta", "Epsilon"},
 				selected: 0,
 				counter:  0,
 			}
 		},
-		Update: func(m interface{}, msg app.Msg) app.UpdateResult {
-			mdl := m.(*model)
+		Update: func(mdl *model, msg app.Msg) app.Result[*model] {
 			switch msg := msg.(type) {
 			case app.KeyMsg:
 				switch msg.Key.Type {
@@ -51,38 +41,36 @@ func main() {
 					mdl.counter++
 				case input.RuneKey:
 					if msg.Key.Rune == 'q' {
-						return app.UpdateResult{Model: nil}
+						return app.Stop(mdl)
 					}
 				}
 			}
-			return app.NoCmd(mdl)
+			return app.Next(mdl)
 		},
-		View: func(m interface{}, focused string) node.Node {
-			mdl := m.(*model)
+		View: func(mdl *model, ctx app.ViewContext) node.Node {
+			theme := ctx.Theme
 
 			items := make([]node.Node, len(mdl.items))
 			for i, item := range mdl.items {
 				prefix := "  "
-				fg := node.Color(7)
-				bg := node.Color(0)
+				fg, bg := theme.FG, theme.BG
 				style := node.StyleFlags(0)
 				if i == mdl.selected {
 					prefix = "> "
-					fg = node.Color(0)
-					bg = node.Color(6)
+					fg, bg = theme.SelectionFG, theme.SelectionBG
 					style = node.Bold
 				}
 				items[i] = node.TextStyled(prefix+item, fg, bg, style)
 			}
 
-			title := node.TextStyled(" tooey demo ", node.Color(0), node.Color(2), node.Bold)
+			title := node.TextStyled(" tooey demo ", 0, theme.Success, node.Bold)
 			counter := node.Text(fmt.Sprintf(" Activations: %d ", mdl.counter))
-			help := node.TextStyled(" ↑/↓ navigate • Enter activate • q quit ", node.Color(8), 0, 0)
+			help := node.TextStyled(fmt.Sprintf(" ↑/↓ navigate • Enter activate • q quit • %dx%d ", ctx.Width, ctx.Height), theme.Muted, 0, 0)
 
 			return node.Column(
 				title,
 				node.Text(""),
-				node.Box(node.BorderRounded, node.Column(items...)),
+				node.Box(node.BorderRounded, node.Column(items...)).WithBorderColor(theme.Border, 0),
 				node.Text(""),
 				counter,
 				node.Spacer(),
@@ -91,7 +79,7 @@ func main() {
 		},
 	}
 
-	if err := a.Run(context.Background()); err != nil && err != context.Canceled {
+	if err := p.Run(context.Background()); err != nil && err != context.Canceled {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
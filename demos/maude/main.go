@@ -10,9 +10,8 @@ import (
 	"github.com/stukennedy/tooey/app"
 	"github.com/stukennedy/tooey/component"
 	"github.com/stukennedy/tooey/input"
+	"github.com/stukennedy/tooey/layout"
 	"github.com/stukennedy/tooey/node"
-
-	"golang.org/x/term"
 )
 
 // --- Message types ---
@@ -42,14 +41,14 @@ type chatMessage struct {
 }
 
 type maudeModel struct {
-	width, height int
-	messages      []chatMessage
-	input         component.TextInput
-	scrollOffset  int
-	thinking      bool
-	pendingReply  int
-	tokenCount    int
-	cost          float64
+	messages     []chatMessage
+	input        component.TextInput
+	scrollOffset int // lines scrolled up from the bottom
+	scroll       layout.ScrollMetrics
+	thinking     bool
+	pendingReply int
+	tokenCount   int
+	cost         float64
 }
 
 // --- Canned responses ---
@@ -100,20 +99,10 @@ const (
 )
 
 func main() {
-	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to set raw mode: %v\n", err)
-		os.Exit(1)
-	}
-	defer term.Restore(int(os.Stdin.Fd()), oldState)
-
 	a := &app.App{
 		Init: func() interface{} {
-			w, h := input.TermSize()
 			return &maudeModel{
-				width:  w,
-				height: h,
-				input:  component.NewTextInput("Send a message..."),
+				input: component.NewTextInput("Send a message..."),
 				messages: []chatMessage{
 					{Role: roleAssistant, Text: "Hello! I'm Maude Code, your AI coding assistant. How can I help you today?"},
 				},
@@ -121,8 +110,8 @@ func main() {
 				cost:       0.001,
 			}
 		},
-		Update: maudeUpdate,
-		View:   maudeView,
+		Update:  maudeUpdate,
+		ViewCtx: maudeView,
 	}
 
 	if err := a.Run(context.Background()); err != nil && err != context.Canceled {
@@ -135,8 +124,6 @@ func maudeUpdate(m interface{}, msg app.Msg) app.UpdateResult {
 	mdl := m.(*maudeModel)
 
 	switch msg := msg.(type) {
-	case app.ResizeMsg:
-		mdl.width, mdl.height = msg.Width, msg.Height
 
 	case app.KeyMsg:
 		switch msg.Key.Type {
@@ -156,17 +143,13 @@ func maudeUpdate(m interface{}, msg app.Msg) app.UpdateResult {
 
 			replyIdx := mdl.pendingReply % len(cannedResponses)
 			mdl.pendingReply++
-			return app.WithCmd(mdl, func() app.Msg {
-				time.Sleep(1500 * time.Millisecond)
+			return app.WithCmd(mdl, app.Tick(1500*time.Millisecond, func(time.Time) app.Msg {
 				return thinkingDoneMsg{reply: cannedResponses[replyIdx]}
-			})
+			}))
 		case input.PageUp:
-			mdl.scrollOffset += 5
+			mdl.scrollBy(max(mdl.scroll.ViewportH-1, 1))
 		case input.PageDown:
-			mdl.scrollOffset -= 5
-			if mdl.scrollOffset < 0 {
-				mdl.scrollOffset = 0
-			}
+			mdl.scrollBy(-max(mdl.scroll.ViewportH-1, 1))
 		default:
 			if !mdl.thinking {
 				mdl.input = mdl.input.Update(msg.Key)
@@ -184,18 +167,27 @@ func maudeUpdate(m interface{}, msg app.Msg) app.UpdateResult {
 		mdl.input.Focused = msg.Focused
 
 	case app.ScrollMsg:
-		mdl.scrollOffset += msg.Delta
-		if mdl.scrollOffset < 0 {
-			mdl.scrollOffset = 0
+		mdl.scrollBy(msg.Delta)
+
+	case app.ScrollMetricsMsg:
+		if msg.Key == "conversation" {
+			mdl.scroll = msg.Metrics
+			mdl.scrollBy(0)
 		}
 	}
 
 	return app.NoCmd(mdl)
 }
 
-func maudeView(m interface{}, focused string) node.Node {
+// scrollBy moves the conversation up by delta lines, keeping the offset
+// within the content reported by layout.
+func (m *maudeModel) scrollBy(delta int) {
+	m.scrollOffset = max(0, min(m.scrollOffset+delta, m.scroll.MaxOffsetY()))
+}
+
+func maudeView(m interface{}, ctx app.ViewContext) node.Node {
 	mdl := m.(*maudeModel)
-	w := mdl.width
+	w := ctx.Width
 
 	// --- Status bar ---
 	left := " Maude Code"
@@ -229,7 +221,7 @@ func maudeView(m interface{}, focused string) node.Node {
 			convChildren = append(convChildren, renderAssistantText(msg.Text)...)
 			for _, tb := range msg.Tools {
 				convChildren = append(convChildren,
-					renderToolBlock(tb, w),
+					renderToolBlock(tb, w-1), // one column goes to the scrollbar
 				)
 			}
 		}
@@ -243,7 +235,9 @@ func maudeView(m interface{}, focused string) node.Node {
 	}
 
 	conversation := node.Column(convChildren...).
+		WithKey("conversation").
 		WithFlex(1).
+		WithScrollbar().
 		WithScrollToBottom().
 		WithScrollOffset(mdl.scrollOffset)
 
@@ -91,6 +91,11 @@ func (m *Manager) FocusableCount() int {
 	return len(m.focusables)
 }
 
+// Focusables returns the keys of the focusable nodes in focus order.
+func (m *Manager) Focusables() []string {
+	return append([]string(nil), m.focusables...)
+}
+
 func collectFocusables(ln layout.LayoutNode, keys *[]string) {
 	if ln.Node.Props.Focusable && ln.Node.Props.Key != "" {
 		*keys = append(*keys, ln.Node.Props.Key)
@@ -98,4 +103,7 @@ func collectFocusables(ln layout.LayoutNode, keys *[]string) {
 	for _, child := range ln.Children {
 		collectFocusables(child, keys)
 	}
+	for _, layer := range ln.Layers {
+		collectFocusables(layer, keys)
+	}
 }
@@ -82,3 +82,38 @@ func TestEmptyFocusables(t *testing.T) {
 	m.Next() // should not panic
 	m.Prev() // should not panic
 }
+
+func TestLayerFocusables(t *testing.T) {
+	tree := node.Column(
+		node.Text("a").WithKey("a").WithFocusable(),
+		node.Modal(node.Text("ok").WithKey("ok").WithFocusable()).WithSize(4, 1),
+	)
+	m := NewManager()
+	m.Update(layout.Layout(tree, 80, 24))
+	if m.FocusableCount() != 2 {
+		t.Fatalf("expected 2 focusables, got %d", m.FocusableCount())
+	}
+	m.Next()
+	if m.Current() != "ok" {
+		t.Fatalf("expected layer button focused, got %q", m.Current())
+	}
+}
+
+func TestFocusables(t *testing.T) {
+	m := NewManager()
+	tree := node.Column(
+		node.Text("a").WithKey("a").WithFocusable(),
+		node.Text("plain"),
+		node.Text("b").WithKey("b").WithFocusable(),
+	)
+	m.Update(layout.Layout(tree, 80, 24))
+
+	keys := m.Focusables()
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Fatalf("expected [a b], got %v", keys)
+	}
+	keys[0] = "z"
+	if m.Current() != "a" {
+		t.Fatal("Focusables should return a copy")
+	}
+}
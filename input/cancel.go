@@ -0,0 +1,110 @@
+package input
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// ErrCanceled is returned from a CancelReader's Read after Cancel.
+var ErrCanceled = errors.New("input: read canceled")
+
+// CancelReader is a reader whose blocked Read can be interrupted, so that
+// another program can take over the terminal's input.
+type CancelReader interface {
+	io.Reader
+
+	// Cancel makes a pending Read, and every later one, return ErrCanceled
+	// without consuming input. It reports whether it could; readers other
+	// than files cannot be interrupted and are left untouched.
+	Cancel() bool
+}
+
+// NewCancelReader wraps r. Files (such as os.Stdin) are read only once
+// select(2) reports input, so Cancel can wake the reader through a pipe.
+func NewCancelReader(r io.Reader) CancelReader {
+	if f, ok := r.(*os.File); ok {
+		if pr, pw, err := os.Pipe(); err == nil {
+			return &fileReader{f: f, fd: int(f.Fd()), wakeR: pr, wakeW: pw, wake: int(pr.Fd())}
+		}
+	}
+	return &plainReader{r: r}
+}
+
+// plainReader cannot be canceled.
+type plainReader struct {
+	r io.Reader
+}
+
+func (r *plainReader) Read(p []byte) (int, error) {
+	return r.r.Read(p)
+}
+
+func (r *plainReader) Cancel() bool {
+	return false
+}
+
+// fileReader waits for the file and a wake-up pipe together.
+type fileReader struct {
+	f            *os.File
+	fd           int
+	wakeR, wakeW *os.File
+	wake         int
+	mu           sync.Mutex
+	canceled     bool
+	closed       bool
+}
+
+func (r *fileReader) Read(p []byte) (int, error) {
+	fd, wake := r.fd, r.wake
+	for {
+		if r.isCanceled() {
+			r.close()
+			return 0, ErrCanceled
+		}
+		var fds unix.FdSet
+		fds.Set(fd)
+		fds.Set(wake)
+		if _, err := unix.Select(max(fd, wake)+1, &fds, nil, nil, nil); err != nil {
+			if err == unix.EINTR {
+				continue
+			}
+			return 0, err
+		}
+		if fds.IsSet(wake) {
+			continue // canceled; checked at the top of the loop
+		}
+		if fds.IsSet(fd) {
+			return r.f.Read(p)
+		}
+	}
+}
+
+func (r *fileReader) Cancel() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.canceled {
+		r.canceled = true
+		r.wakeW.Write([]byte{0})
+	}
+	return true
+}
+
+func (r *fileReader) isCanceled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.canceled
+}
+
+func (r *fileReader) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.closed {
+		r.closed = true
+		r.wakeR.Close()
+		r.wakeW.Close()
+	}
+}
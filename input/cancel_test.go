@@ -0,0 +1,59 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCancelReaderInterruptsFileRead(t *testing.T) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	defer pw.Close()
+
+	r := NewCancelReader(pr)
+	pw.Write([]byte("a"))
+	buf := make([]byte, 8)
+	if n, err := r.Read(buf); err != nil || string(buf[:n]) != "a" {
+		t.Fatalf("expected to read %q, got %q, %v", "a", buf[:n], err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.Read(buf)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if !r.Cancel() {
+		t.Fatal("expected a file read to be interruptible")
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrCanceled) {
+			t.Fatalf("expected ErrCanceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Cancel to wake the pending read")
+	}
+
+	// Input that arrives later is left for the next reader
+	pw.Write([]byte("b"))
+	if n, _ := pr.Read(buf); string(buf[:n]) != "b" {
+		t.Fatalf("expected the input to be left unread, got %q", buf[:n])
+	}
+}
+
+func TestCancelReaderPlain(t *testing.T) {
+	r := NewCancelReader(bytes.NewReader([]byte("abc")))
+	if r.Cancel() {
+		t.Fatal("expected a plain reader not to be cancelable")
+	}
+	if n, err := r.Read(make([]byte, 3)); n != 3 || err != nil {
+		t.Fatalf("expected reads to continue, got %d, %v", n, err)
+	}
+}
@@ -44,6 +44,18 @@ const (
 	MouseScrollDown
 	AltLeft
 	AltRight
+	F1
+	F2
+	F3
+	F4
+	F5
+	F6
+	F7
+	F8
+	F9
+	F10
+	F11
+	F12
 )
 
 // Key represents a keyboard input event.
@@ -226,6 +238,12 @@ func parseInput(data []byte) []Key {
 					continue
 				}
 			}
+			// SS3 function keys: \x1bOP..\x1bOS (F1-F4)
+			if i+2 < len(data) && data[i+1] == 'O' && data[i+2] >= 'P' && data[i+2] <= 'S' {
+				keys = append(keys, Key{Type: F1 + KeyType(data[i+2]-'P')})
+				i += 3
+				continue
+			}
 			// Alt+Enter (ESC followed by CR or LF) → ShiftEnter
 			if i+1 < len(data) && (data[i+1] == '\r' || data[i+1] == '\n') {
 				keys = append(keys, Key{Type: ShiftEnter})
@@ -267,6 +285,13 @@ func parseInput(data []byte) []Key {
 	return keys
 }
 
+// functionKeys maps the number in a \x1b[NN~ sequence to its function key.
+var functionKeys = map[string]KeyType{
+	"11": F1, "12": F2, "13": F3, "14": F4,
+	"15": F5, "17": F6, "18": F7, "19": F8,
+	"20": F9, "21": F10, "23": F11, "24": F12,
+}
+
 func parseCSI(data []byte) (Key, int) {
 	if len(data) == 0 {
 		return Key{}, 0
@@ -311,6 +336,12 @@ func parseCSI(data []byte) (Key, int) {
 			return Key{Type: PageDown}, 2
 		}
 	}
+	// Function keys: \x1b[11~ (F1) to \x1b[24~ (F12)
+	if len(data) >= 3 && data[2] == '~' {
+		if k, ok := functionKeys[string(data[:2])]; ok {
+			return Key{Type: k}, 3
+		}
+	}
 	// Kitty keyboard protocol: \x1b[13;2u = Shift+Enter
 	if len(data) >= 4 && data[0] == '1' && data[1] == '3' && data[2] == ';' && data[3] == '2' {
 		if len(data) >= 5 && data[4] == 'u' {
@@ -58,3 +58,22 @@ func TestParsePageKeys(t *testing.T) {
 		t.Fatalf("expected PageUp, got %v", keys)
 	}
 }
+
+func TestParseFunctionKeys(t *testing.T) {
+	tests := []struct {
+		input    []byte
+		expected KeyType
+	}{
+		{[]byte("\x1bOP"), F1},
+		{[]byte("\x1bOS"), F4},
+		{[]byte("\x1b[15~"), F5},
+		{[]byte("\x1b[21~"), F10},
+		{[]byte("\x1b[24~"), F12},
+	}
+	for _, tt := range tests {
+		keys := parseInput(tt.input)
+		if len(keys) != 1 || keys[0].Type != tt.expected {
+			t.Errorf("input %q: expected %d, got %v", tt.input, tt.expected, keys)
+		}
+	}
+}
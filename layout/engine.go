@@ -0,0 +1,138 @@
+package layout
+
+import "github.com/stukennedy/tooey/node"
+
+// Engine lays out node trees frame after frame. Within a frame every node is
+// measured at most once per available size; across frames it keeps wrapped
+// text and, for nodes with a Props.Key and Props.Version, whole subtree
+// layouts whose version and available rect have not changed. An Engine is not safe for concurrent use.
+type Engine struct {
+	// Per-frame measurements, keyed by node identity and available size
+	widths  map[measureKey]int
+	heights map[measureKey]int
+
+	// Wrapped text and keyed subtrees from this frame and the one before.
+	// Entries not used for a whole frame are dropped.
+	text, prevText         map[textKey][]Line
+	subtrees, prevSubtrees map[string]subtree
+	rows, prevRows         map[rowKey]cachedRow
+}
+
+// measureKey is the available width and height a node was measured at.
+// Widths leave h zero; heights need it as a VirtualList, and so whatever
+// contains one, measures only as many rows as fit.
+type measureKey struct {
+	n    *node.Node
+	w, h int
+}
+
+type textKey struct {
+	text  string
+	width int
+	mode  node.Overflow
+}
+
+type subtree struct {
+	version int
+	avail   Rect
+	ln      LayoutNode
+}
+
+// NewEngine returns an engine with empty caches.
+func NewEngine() *Engine {
+	return &Engine{
+		widths:   map[measureKey]int{},
+		heights:  map[measureKey]int{},
+		text:     map[textKey][]Line{},
+		subtrees: map[string]subtree{},
+		rows:     map[rowKey]cachedRow{},
+	}
+}
+
+// Layout computes positions for the node tree within the given terminal size.
+func (e *Engine) Layout(root node.Node, termW, termH int) LayoutNode {
+	e.widths = map[measureKey]int{}
+	e.heights = map[measureKey]int{}
+	e.prevText, e.text = e.text, map[textKey][]Line{}
+	e.prevSubtrees, e.subtrees = e.subtrees, map[string]subtree{}
+	e.prevRows, e.rows = e.rows, map[rowKey]cachedRow{}
+
+	screen := Rect{0, 0, termW, termH}
+	ln := e.layout(&root, screen)
+	ln.Layers = e.resolveLayers(ln, screen)
+	return ln
+}
+
+// textLines is TextLines backed by the engine's wrapped text cache.
+func (e *Engine) textLines(n *node.Node, width int) []Line {
+	k := textKey{n.Props.Text, width, n.Props.Overflow}
+	if lines, ok := e.text[k]; ok {
+		return lines
+	}
+	lines, ok := e.prevText[k]
+	if !ok {
+		lines = TextLines(*n, width)
+	}
+	e.text[k] = lines
+	return lines
+}
+
+// reuse returns last frame's layout of a versioned keyed node if neither its
+// version nor its available rect changed.
+func (e *Engine) reuse(n *node.Node, avail Rect) (LayoutNode, bool) {
+	if n.Props.Key == "" || n.Props.Version == 0 {
+		return LayoutNode{}, false
+	}
+	s, ok := e.prevSubtrees[n.Props.Key]
+	if !ok {
+		s, ok = e.subtrees[n.Props.Key]
+	}
+	if !ok || s.avail != avail || s.version != n.Props.Version {
+		return LayoutNode{}, false
+	}
+	e.subtrees[n.Props.Key] = s
+	return clone(s.ln), true
+}
+
+// remember stores a keyed node's layout for reuse in the next frame.
+func (e *Engine) remember(n *node.Node, avail Rect, ln LayoutNode) {
+	if n.Props.Key == "" || n.Props.Version == 0 {
+		return
+	}
+	e.subtrees[n.Props.Key] = subtree{version: n.Props.Version, avail: avail, ln: clone(ln)}
+}
+
+// clone deep-copies a layout so callers can shift it without touching the
+// cached copy.
+func clone(ln LayoutNode) LayoutNode {
+	if ln.Children != nil {
+		children := make([]LayoutNode, len(ln.Children))
+		for i, c := range ln.Children {
+			children[i] = clone(c)
+		}
+		ln.Children = children
+	}
+	return ln
+}
+
+// measureWidth returns the intrinsic width of a non-flex node.
+func (e *Engine) measureWidth(n *node.Node, avail Rect) int {
+	k := measureKey{n, avail.W, 0}
+	if w, ok := e.widths[k]; ok {
+		return w
+	}
+	w := e.measureWidthUncached(n, avail)
+	e.widths[k] = w
+	return w
+}
+
+// measureHeight returns the intrinsic height of a non-flex node.
+func (e *Engine) measureHeight(n *node.Node, avail Rect) int {
+	k := measureKey{n, avail.W, avail.H}
+	if h, ok := e.heights[k]; ok {
+		return h
+	}
+	h := e.measureHeightUncached(n, avail)
+	e.heights[k] = h
+	return h
+}
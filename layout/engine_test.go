@@ -0,0 +1,124 @@
+package layout
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stukennedy/tooey/node"
+)
+
+func chatLog(n int) node.Node {
+	var msgs []node.Node
+	for i := 0; i < n; i++ {
+		msgs = append(msgs, node.Box(node.BorderRounded, node.Column(
+			node.Text("user"),
+			node.Text("a reply long enough to wrap over several lines of the log"),
+		)))
+	}
+	return node.Column(msgs...).WithScrollToBottom()
+}
+
+func TestEngineMatchesLayout(t *testing.T) {
+	tree := node.Column(
+		chatLog(3).WithFlex(1).WithKey("log"),
+		node.Row(node.Text("> "), node.Text("input").WithFlex(1)),
+	)
+	want := Layout(tree, 30, 12)
+	e := NewEngine()
+	for frame := 0; frame < 3; frame++ {
+		if got := e.Layout(tree, 30, 12); !reflect.DeepEqual(got, want) {
+			t.Fatalf("frame %d: engine layout differs from Layout", frame)
+		}
+	}
+}
+
+func TestEngineMeasuresOncePerFrame(t *testing.T) {
+	e := NewEngine()
+	e.Layout(chatLog(20), 30, 12)
+	// One wrap of each distinct text per width: the log width and the box
+	// interior the messages are laid out at
+	if len(e.text) > 4 {
+		t.Fatalf("expected at most 4 wrapped texts, got %d", len(e.text))
+	}
+}
+
+func TestEngineReusesWrappedText(t *testing.T) {
+	e := NewEngine()
+	first := e.Layout(node.Text("some wrapped text"), 6, 5)
+	second := e.Layout(node.Text("some wrapped text"), 6, 5)
+	if &first.Lines[0] != &second.Lines[0] {
+		t.Fatal("expected wrapped lines to be reused across frames")
+	}
+}
+
+func TestEngineKeyedSubtree(t *testing.T) {
+	e := NewEngine()
+	tree := node.Column(chatLog(5).WithKey("log").WithVersion(1))
+	first := e.Layout(tree, 30, 10)
+	first.Children[0].Children[0].Rect.Y = 99
+
+	// Unchanged: the cached layout comes back untouched by the caller's edit
+	second := e.Layout(tree, 30, 10)
+	if second.Children[0].Children[0].Rect.Y == 99 {
+		t.Fatal("cached subtree was modified through a returned layout")
+	}
+	if !reflect.DeepEqual(second, Layout(tree, 30, 10)) {
+		t.Fatal("reused subtree differs from a fresh layout")
+	}
+
+	// A new version or size is laid out again
+	changed := node.Column(chatLog(6).WithKey("log").WithVersion(2))
+	if got := e.Layout(changed, 30, 10); !reflect.DeepEqual(got, Layout(changed, 30, 10)) {
+		t.Fatal("changed subtree was not laid out again")
+	}
+	if got := e.Layout(changed, 20, 10); !reflect.DeepEqual(got, Layout(changed, 20, 10)) {
+		t.Fatal("resized subtree was not laid out again")
+	}
+}
+
+func TestEngineReusesVersionedSubtree(t *testing.T) {
+	rows := 0
+	list := func(version int) node.Node {
+		return node.Column(node.VirtualList(100, func(i int) node.Node {
+			rows++
+			return node.Text("row")
+		}).WithFlex(1).WithKey("list").WithVersion(version))
+	}
+	e := NewEngine()
+	e.Layout(list(1), 30, 10)
+	built := rows
+	e.Layout(list(1), 30, 10)
+	if rows != built {
+		t.Fatalf("expected the versioned list to be reused, built %d more rows", rows-built)
+	}
+	e.Layout(list(2), 30, 10)
+	if rows == built {
+		t.Fatal("expected a new version to be laid out again")
+	}
+
+	// Without a version the subtree is always laid out again
+	e = NewEngine()
+	unversioned := node.Column(node.Text("a").WithKey("a"))
+	e.Layout(unversioned, 30, 10)
+	if len(e.subtrees) != 0 {
+		t.Fatal("expected unversioned subtrees not to be cached")
+	}
+}
+
+func TestTextLinesFollowExplicitWidth(t *testing.T) {
+	ln := Layout(node.Text("abc def ghi").WithSize(4, 0), 20, 5)
+	if ln.Rect.W != 4 || len(ln.Lines) != 3 {
+		t.Fatalf("expected 3 lines at width 4, got %d at width %d", len(ln.Lines), ln.Rect.W)
+	}
+	if ln.Rect.H != 3 {
+		t.Fatalf("expected height 3 for the narrowed text, got %d", ln.Rect.H)
+	}
+}
+
+func BenchmarkEngineChatLog(b *testing.B) {
+	tree := chatLog(2000)
+	e := NewEngine()
+	for i := 0; i < b.N; i++ {
+		e.Layout(tree, 100, 40)
+	}
+}
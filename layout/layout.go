@@ -1,8 +1,7 @@
 package layout
 
 import (
-	"strings"
-	"unicode/utf8"
+	"sort"
 
 	"github.com/stukennedy/tooey/node"
 )
@@ -17,242 +16,827 @@ type LayoutNode struct {
 	Node     node.Node
 	Rect     Rect
 	Children []LayoutNode
+
+	// Scroll is set on scrollable containers: Columns with a scroll offset,
+	// ScrollToBottom or a scrollbar, and anything with horizontal scrolling.
+	Scroll *ScrollMetrics
+
+	// Lines holds a Text node's display lines at Rect.W, so painting does
+	// not wrap the text again.
+	Lines []Line
+
+	// Layers holds the floating layers of the tree, resolved against their
+	// anchors and sorted into paint order. Only the root carries layers.
+	Layers []LayoutNode
 }
 
 // Layout computes positions for the node tree within the given terminal size.
+// It uses a fresh Engine; callers laying out frame after frame should keep
+// one Engine so its caches carry over.
 func Layout(root node.Node, termW, termH int) LayoutNode {
-	return layout(root, Rect{0, 0, termW, termH})
+	return NewEngine().Layout(root, termW, termH)
+}
+
+// Find returns the laid out node with the given key, searching the main tree
+// first and then any layers.
+func Find(tree LayoutNode, key string) (LayoutNode, bool) {
+	if found, ok := find(tree, key); ok {
+		return found, true
+	}
+	for _, l := range tree.Layers {
+		if found, ok := find(l, key); ok {
+			return found, true
+		}
+	}
+	return LayoutNode{}, false
 }
 
-func layout(n node.Node, avail Rect) LayoutNode {
-	ln := LayoutNode{Node: n, Rect: avail}
+func find(ln LayoutNode, key string) (LayoutNode, bool) {
+	if ln.Node.Props.Key == key {
+		return ln, true
+	}
+	for _, c := range ln.Children {
+		if found, ok := find(c, key); ok {
+			return found, true
+		}
+	}
+	return LayoutNode{}, false
+}
+
+func (e *Engine) layout(n *node.Node, avail Rect) LayoutNode {
+	if ln, ok := e.reuse(n, avail); ok {
+		return ln
+	}
+	ln := LayoutNode{Node: *n, Rect: avail}
 
 	switch n.Type {
 	case node.TextNode:
-		ln = layoutText(n, avail)
+		ln = e.layoutText(n, avail)
 	case node.RowNode:
-		ln = layoutRow(n, avail)
+		ln = e.layoutRow(n, avail)
 	case node.ColumnNode, node.ListNode, node.PaneNode:
-		ln = layoutColumn(n, avail)
+		ln = e.layoutColumn(n, avail)
 	case node.BoxNode:
-		ln = layoutBox(n, avail)
+		ln = e.layoutBox(n, avail)
+	case node.GridNode:
+		ln = e.layoutGrid(n, avail)
+	case node.VirtualListNode:
+		ln = e.layoutVirtual(n, avail)
 	case node.SpacerNode:
 		ln.Rect = avail
+	case node.LayerNode:
+		// Layers take no space in the flow; resolveLayers places them later.
+		ln.Rect = Rect{avail.X, avail.Y, 0, 0}
 	}
 
-	// Apply explicit size constraints
+	// Apply explicit size constraints; narrowed text wraps to more lines
 	if n.Props.Width > 0 && n.Props.Width < ln.Rect.W {
 		ln.Rect.W = n.Props.Width
 	}
+	if ln.Lines != nil && ln.Rect.W != avail.W {
+		ln.Lines = e.textLines(n, ln.Rect.W)
+		ln.Rect.H = min(len(ln.Lines), avail.H)
+	}
 	if n.Props.Height > 0 && n.Props.Height < ln.Rect.H {
 		ln.Rect.H = n.Props.Height
 	}
 
+	e.remember(n, avail, ln)
 	return ln
 }
 
-func layoutText(n node.Node, avail Rect) LayoutNode {
-	lines := wrapText(n.Props.Text, avail.W)
-	h := len(lines)
-	if h > avail.H {
-		h = avail.H
-	}
+func (e *Engine) layoutText(n *node.Node, avail Rect) LayoutNode {
+	lines := e.textLines(n, avail.W)
+	h := min(len(lines), avail.H)
 	// Text uses the full available width (important for flex-allocated space)
-	return LayoutNode{
-		Node: n,
-		Rect: Rect{avail.X, avail.Y, avail.W, h},
+	ln := LayoutNode{
+		Node:  *n,
+		Rect:  Rect{avail.X, avail.Y, avail.W, h},
+		Lines: lines,
+	}
+
+	// Horizontal scroll pans the lines, clamped to the longest one
+	if n.Props.HScroll {
+		longest := 0
+		for _, line := range lines {
+			longest = max(longest, line.Width())
+		}
+		ln.Scroll = &ScrollMetrics{
+			ContentW: max(longest, avail.W), ContentH: len(lines),
+			ViewportW: avail.W, ViewportH: h,
+			OffsetX: max(0, min(n.Props.ScrollX, longest-avail.W)),
+		}
 	}
+	return ln
 }
 
-func layoutRow(n node.Node, avail Rect) LayoutNode {
-	ln := LayoutNode{Node: n, Rect: avail}
+func (e *Engine) layoutRow(n *node.Node, avail Rect) LayoutNode {
+	ln := LayoutNode{Node: *n, Rect: avail}
 	if len(n.Children) == 0 {
 		return ln
 	}
 
-	// First pass: measure non-flex children
-	totalFixed := 0
-	totalFlex := 0
-	for _, child := range n.Children {
-		fw := flexWeight(child)
-		if fw > 0 {
-			totalFlex += fw
-		} else {
-			totalFixed += measureWidth(child, avail)
+	if n.Props.FlexWrap {
+		y := avail.Y
+		for _, line := range e.rowLines(n, avail) {
+			e.placeRow(&ln, n, line, Rect{avail.X, y, avail.W, line.h})
+			y += line.h + n.Props.RowGap
 		}
+		return ln
 	}
 
-	remaining := avail.W - totalFixed
-	if remaining < 0 {
-		remaining = 0
+	widths, used := e.rowWidths(n, avail)
+	e.placeRow(&ln, n, rowLine{0, len(n.Children), widths, used, avail.H}, avail)
+
+	if n.Props.HScroll {
+		offsetX := scrollChildrenX(&ln, n.Props.ScrollX, used-avail.W)
+		ln.Scroll = &ScrollMetrics{
+			ContentW: max(used, avail.W), ContentH: avail.H,
+			ViewportW: avail.W, ViewportH: avail.H,
+			OffsetX: offsetX,
+		}
 	}
 
-	// Second pass: assign positions
-	x := avail.X
-	for _, child := range n.Children {
-		fw := flexWeight(child)
-		var childW int
-		if fw > 0 && totalFlex > 0 {
-			childW = (remaining * fw) / totalFlex
-		} else {
-			childW = measureWidth(child, avail)
+	return ln
+}
+
+// rowLine is a run of Row children laid out side by side: the children
+// [start, end) with their widths, the width used including gaps, and the
+// line's height.
+type rowLine struct {
+	start, end int
+	widths     []int
+	used       int
+	h          int
+}
+
+// placeRow lays out the children of one row line within rect, distributing
+// free space by the row's justify and aligning each child on the cross axis.
+func (e *Engine) placeRow(ln *LayoutNode, n *node.Node, line rowLine, rect Rect) {
+	free := rect.W - line.used
+	count := line.end - line.start
+	x := rect.X
+	for i := 0; i < count; i++ {
+		child := &n.Children[line.start+i]
+		childRect := Rect{x + justifyOffset(n.Props.Justify, free, i, count), rect.Y, line.widths[i], rect.H}
+		if n.Props.Align != node.AlignStretch {
+			h := min(e.measureHeight(child, childRect), rect.H)
+			childRect.Y += alignOffset(n.Props.Align, rect.H-h)
+			childRect.H = h
 		}
-		if childW > avail.W-(x-avail.X) {
-			childW = avail.W - (x - avail.X)
+		ln.Children = append(ln.Children, e.layout(child, childRect))
+		x += line.widths[i] + n.Props.ColGap
+	}
+}
+
+// rowLines breaks a wrapping Row into lines: children are added to a line
+// while their bases fit, and every line holds at least one child. Each line
+// then grows and shrinks on its own.
+func (e *Engine) rowLines(n *node.Node, avail Rect) []rowLine {
+	gap := n.Props.ColGap
+	var lines []rowLine
+	start, pos := 0, 0
+	for i := range n.Children {
+		w := e.basis(&n.Children[i], avail)
+		if i > start && pos+gap+w > avail.W {
+			lines = append(lines, e.rowLine(n, start, i, avail))
+			start, pos = i, 0
 		}
-		if childW < 0 {
-			childW = 0
+		if i > start {
+			pos += gap
 		}
-		childRect := Rect{x, avail.Y, childW, avail.H}
-		ln.Children = append(ln.Children, layout(child, childRect))
-		x += childW
+		pos += w
 	}
+	return append(lines, e.rowLine(n, start, len(n.Children), avail))
+}
 
-	return ln
+func (e *Engine) rowLine(n *node.Node, start, end int, avail Rect) rowLine {
+	widths, used := e.fitWidths(n.Children[start:end], avail, n.Props.ColGap, !n.Props.HScroll)
+	h := 0
+	for i := start; i < end; i++ {
+		c := &n.Children[i]
+		if c.Type == node.SpacerNode {
+			continue
+		}
+		h = max(h, e.measureHeight(c, Rect{avail.X, avail.Y, widths[i-start], avail.H}))
+	}
+	return rowLine{start, end, widths, used, max(h, 1)}
 }
 
-func layoutColumn(n node.Node, avail Rect) LayoutNode {
-	ln := LayoutNode{Node: n, Rect: avail}
-	if len(n.Children) == 0 {
-		return ln
+// rowWidths resolves the width of each child of a non-wrapping Row.
+func (e *Engine) rowWidths(n *node.Node, avail Rect) (widths []int, used int) {
+	return e.fitWidths(n.Children, avail, n.Props.ColGap, !n.Props.HScroll)
+}
+
+// basis returns the width a Row child starts from: FlexBasis if set,
+// otherwise 0 for flex children and the intrinsic width for the rest.
+func (e *Engine) basis(c *node.Node, avail Rect) int {
+	if c.Props.FlexBasis > 0 {
+		return c.Props.FlexBasis
+	}
+	if flexWeight(c) > 0 {
+		return 0
+	}
+	return e.measureWidth(c, avail)
+}
+
+// fitWidths resolves the widths of children placed side by side in avail.W
+// with gap cells between them. Children start at their basis; free space goes
+// to flex children by weight, and overflow is taken from children with a
+// FlexShrink factor in proportion to factor times basis. With clamp set,
+// whatever still overflows is cut from the last children.
+func (e *Engine) fitWidths(children []node.Node, avail Rect, gap int, clamp bool) (widths []int, used int) {
+	widths = make([]int, len(children))
+	used = gap * max(len(children)-1, 0)
+	totalFlex := 0
+	for i := range children {
+		c := &children[i]
+		widths[i] = e.basis(c, avail)
+		used += widths[i]
+		totalFlex += flexWeight(c)
+	}
+
+	free := avail.W - used
+	switch {
+	case free > 0 && totalFlex > 0:
+		for i := range children {
+			if fw := flexWeight(&children[i]); fw > 0 {
+				grow := (free * fw) / totalFlex
+				widths[i] += grow
+				used += grow
+			}
+		}
+	case free < 0:
+		used -= shrinkWidths(children, widths, -free)
+	}
+
+	if clamp {
+		pos := 0
+		for i := range widths {
+			widths[i] = max(0, min(widths[i], avail.W-pos))
+			pos += widths[i] + gap
+		}
+		used = max(pos-gap, 0)
+	}
+	return widths, used
+}
+
+// shrinkWidths takes up to over cells from shrinkable children and returns
+// how many it took.
+func shrinkWidths(children []node.Node, widths []int, over int) int {
+	total := 0
+	for i := range children {
+		total += children[i].Props.FlexShrink * widths[i]
+	}
+	if total == 0 {
+		return 0
 	}
+	taken := 0
+	for i := range children {
+		cut := min(over*children[i].Props.FlexShrink*widths[i]/total, widths[i])
+		widths[i] -= cut
+		taken += cut
+	}
+	// Rounding leaves a few cells; take them all from the last shrinkable
+	// child with width left, moving to earlier ones only if it runs out
+	for i := len(children) - 1; i >= 0 && taken < over; i-- {
+		if children[i].Props.FlexShrink > 0 && widths[i] > 0 {
+			cut := min(widths[i], over-taken)
+			widths[i] -= cut
+			taken += cut
+		}
+	}
+	return taken
+}
 
-	scrollable := n.Props.ScrollOffset > 0 || n.Props.ScrollToBottom
+func (e *Engine) layoutColumn(n *node.Node, avail Rect) LayoutNode {
+	ln := LayoutNode{Node: *n, Rect: avail}
+	scrollable := n.Props.ScrollOffset > 0 || n.Props.ScrollToBottom || n.Props.Scrollbar
+	if len(n.Children) == 0 && !scrollable {
+		return ln
+	}
+	area := columnArea(n, avail)
 
 	// First pass: measure non-flex children
 	totalFixed := 0
 	totalFlex := 0
-	for _, child := range n.Children {
+	for i := range n.Children {
+		child := &n.Children[i]
 		fw := flexWeight(child)
 		if fw > 0 {
 			totalFlex += fw
 		} else {
-			totalFixed += measureHeight(child, avail)
+			totalFixed += e.measureHeight(child, area)
 		}
 	}
 
-	remaining := avail.H - totalFixed
+	remaining := area.H - totalFixed
 	if remaining < 0 {
 		remaining = 0
 	}
 
-	// Second pass: assign positions
-	y := avail.Y
-	for _, child := range n.Children {
+	// Second pass: resolve heights
+	heights := make([]int, len(n.Children))
+	used := 0
+	for i := range n.Children {
+		child := &n.Children[i]
 		fw := flexWeight(child)
 		var childH int
 		if fw > 0 && totalFlex > 0 {
 			childH = (remaining * fw) / totalFlex
 		} else {
-			childH = measureHeight(child, avail)
+			childH = e.measureHeight(child, area)
 		}
 		if !scrollable {
-			if childH > avail.H-(y-avail.Y) {
-				childH = avail.H - (y - avail.Y)
+			if childH > area.H-used {
+				childH = area.H - used
 			}
 			if childH < 0 {
 				childH = 0
 			}
 		}
-		childRect := Rect{avail.X, y, avail.W, childH}
-		ln.Children = append(ln.Children, layout(child, childRect))
-		y += childH
+		heights[i] = childH
+		used += childH
+	}
+
+	// Third pass: assign positions, distributing any free space
+	free := area.H - used
+	y := area.Y
+	contentW := area.W
+	for i := range n.Children {
+		child := &n.Children[i]
+		childRect := Rect{area.X, y + justifyOffset(n.Props.Justify, free, i, len(n.Children)), area.W, heights[i]}
+		if n.Props.HScroll {
+			// Children keep their natural width so they can be panned
+			childRect.W = max(area.W, e.measureWidth(child, area))
+			contentW = max(contentW, childRect.W)
+		} else if n.Props.Align != node.AlignStretch {
+			w := min(e.measureWidth(child, area), area.W)
+			childRect.X += alignOffset(n.Props.Align, area.W-w)
+			childRect.W = w
+		}
+		ln.Children = append(ln.Children, e.layout(child, childRect))
+		y += heights[i]
 	}
 
-	// Apply scroll offset: shift children upward
+	// Apply scroll offset: shift children upward, never past the content
+	contentH := y - area.Y
+	maxOffset := max(contentH-area.H, 0)
 	scrollOffset := n.Props.ScrollOffset
 	if n.Props.ScrollToBottom {
-		totalContentH := y - avail.Y
-		if totalContentH > avail.H {
-			autoOffset := totalContentH - avail.H
-			// Manual scroll (scrollOffset) adjusts from the auto-scroll position
-			scrollOffset = autoOffset - n.Props.ScrollOffset
-			if scrollOffset < 0 {
-				scrollOffset = 0
-			}
-		} else {
-			scrollOffset = 0
-		}
+		// Manual scroll (scrollOffset) adjusts from the auto-scroll position
+		scrollOffset = maxOffset - n.Props.ScrollOffset
 	}
+	scrollOffset = max(0, min(scrollOffset, maxOffset))
 	if scrollOffset > 0 {
 		for i := range ln.Children {
 			shiftY(&ln.Children[i], -scrollOffset)
 		}
 	}
+	offsetX := 0
+	if n.Props.HScroll {
+		offsetX = scrollChildrenX(&ln, n.Props.ScrollX, contentW-area.W)
+	}
 
+	if scrollable || n.Props.HScroll {
+		ln.Scroll = &ScrollMetrics{
+			ContentW: contentW, ContentH: contentH,
+			ViewportW: area.W, ViewportH: area.H,
+			OffsetX: offsetX, OffsetY: scrollOffset,
+		}
+	}
 	return ln
 }
 
-func layoutBox(n node.Node, avail Rect) LayoutNode {
-	ln := LayoutNode{Node: n, Rect: avail}
+// columnArea returns the part of a Column's rect available to its children,
+// leaving out the scrollbar gutter.
+func columnArea(n *node.Node, avail Rect) Rect {
+	if n.Props.Scrollbar {
+		avail.W = max(avail.W-1, 0)
+	}
+	return avail
+}
+
+func (e *Engine) layoutBox(n *node.Node, avail Rect) LayoutNode {
+	ln := LayoutNode{Node: *n, Rect: avail}
 	if len(n.Children) == 0 {
 		return ln
 	}
-	// Border takes 1 cell on each side
-	innerRect := Rect{
-		X: avail.X + 1,
-		Y: avail.Y + 1,
-		W: avail.W - 2,
-		H: avail.H - 2,
+	ln.Children = append(ln.Children, e.layout(&n.Children[0], boxInner(n, avail)))
+	return ln
+}
+
+// boxInsets returns the cells a Box's border takes on each side: one for
+// every side that has a border.
+func boxInsets(n *node.Node) (top, right, bottom, left int) {
+	side := func(s node.Sides) int {
+		if n.Props.Sides.Has(s) {
+			return 1
+		}
+		return 0
+	}
+	return side(node.SideTop), side(node.SideRight), side(node.SideBottom), side(node.SideLeft)
+}
+
+// boxInner returns the rect inside a Box's border.
+func boxInner(n *node.Node, avail Rect) Rect {
+	top, right, bottom, left := boxInsets(n)
+	return Rect{
+		X: avail.X + left,
+		Y: avail.Y + top,
+		W: max(avail.W-left-right, 0),
+		H: max(avail.H-top-bottom, 0),
 	}
-	if innerRect.W < 0 {
-		innerRect.W = 0
+}
+
+func (e *Engine) layoutGrid(n *node.Node, avail Rect) LayoutNode {
+	ln := LayoutNode{Node: *n, Rect: avail}
+	if len(n.Children) == 0 {
+		return ln
 	}
-	if innerRect.H < 0 {
-		innerRect.H = 0
+	g := e.resolveGrid(n, avail, avail.H)
+	for i := range n.Children {
+		child := &n.Children[i]
+		ln.Children = append(ln.Children, e.layout(child, g.rect(g.cells[i])))
 	}
-	ln.Children = append(ln.Children, layout(n.Children[0], innerRect))
 	return ln
 }
 
-// measureWidth returns the intrinsic width of a non-flex node.
-func measureWidth(n node.Node, avail Rect) int {
+// gridCell is the area a grid child occupies: 0-based, end exclusive.
+type gridCell struct {
+	row, col, rowEnd, colEnd int
+}
+
+// gridTracks holds resolved grid track positions and sizes.
+type gridTracks struct {
+	cells      []gridCell
+	colX, colW []int
+	rowY, rowH []int
+}
+
+// rect returns the screen rect covered by a cell area, including inner gaps.
+func (g gridTracks) rect(c gridCell) Rect {
+	last := c.colEnd - 1
+	bottom := c.rowEnd - 1
+	return Rect{
+		X: g.colX[c.col],
+		Y: g.rowY[c.row],
+		W: g.colX[last] + g.colW[last] - g.colX[c.col],
+		H: g.rowY[bottom] + g.rowH[bottom] - g.rowY[c.row],
+	}
+}
+
+// width returns the total width of the columns and gaps.
+func (g gridTracks) width() int {
+	last := len(g.colX) - 1
+	return g.colX[last] + g.colW[last] - g.colX[0]
+}
+
+// height returns the total height of the rows and gaps.
+func (g gridTracks) height() int {
+	if len(g.rowY) == 0 {
+		return 0
+	}
+	last := len(g.rowY) - 1
+	return g.rowY[last] + g.rowH[last] - g.rowY[0]
+}
+
+// resolveGrid places grid children and sizes the tracks. Flexible rows share
+// availH; pass 0 to size them to their content.
+func (e *Engine) resolveGrid(n *node.Node, avail Rect, availH int) gridTracks {
+	cells, rows, cols := placeGrid(n)
+	p := n.Props
+
+	colW := sizeTracks(p.GridColumns, cols, avail.W, p.ColGap, false, func(i int) int {
+		w := 0
+		for ci, c := range cells {
+			if c.col == i && c.colEnd == i+1 {
+				w = max(w, e.measureWidth(&n.Children[ci], avail))
+			}
+		}
+		return w
+	})
+	colX := trackStarts(avail.X, colW, p.ColGap)
+
+	rowH := sizeTracks(p.GridRows, rows, availH, p.RowGap, true, func(i int) int {
+		h := 0
+		for ci, c := range cells {
+			if c.row == i && c.rowEnd == i+1 {
+				last := c.colEnd - 1
+				w := colX[last] + colW[last] - colX[c.col]
+				h = max(h, e.measureHeight(&n.Children[ci], Rect{avail.X, avail.Y, w, avail.H}))
+			}
+		}
+		return h
+	})
+	rowY := trackStarts(avail.Y, rowH, p.RowGap)
+
+	return gridTracks{cells: cells, colX: colX, colW: colW, rowY: rowY, rowH: rowH}
+}
+
+// placeGrid assigns every child a cell area. Explicitly placed children are
+// positioned first; the rest fill free cells in row-major order.
+func placeGrid(n *node.Node) (cells []gridCell, rows, cols int) {
+	cols = max(len(n.Props.GridColumns), 1)
+	cells = make([]gridCell, len(n.Children))
+	taken := map[[2]int]bool{}
+	mark := func(c gridCell) {
+		for r := c.row; r < c.rowEnd; r++ {
+			for col := c.col; col < c.colEnd; col++ {
+				taken[[2]int{r, col}] = true
+			}
+		}
+	}
+	free := func(c gridCell) bool {
+		for r := c.row; r < c.rowEnd; r++ {
+			for col := c.col; col < c.colEnd; col++ {
+				if taken[[2]int{r, col}] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	span := func(child *node.Node) (int, int) {
+		return max(child.Props.RowSpan, 1), min(max(child.Props.ColSpan, 1), cols)
+	}
+
+	explicit := func(child *node.Node) bool {
+		return child.Props.GridRow > 0 || child.Props.GridCol > 0
+	}
+	for i := range n.Children {
+		child := &n.Children[i]
+		if !explicit(child) {
+			continue
+		}
+		rs, cs := span(child)
+		row := max(child.Props.GridRow, 1) - 1
+		col := min(max(child.Props.GridCol, 1)-1, cols-1)
+		cells[i] = gridCell{row, col, row + rs, min(col+cs, cols)}
+		mark(cells[i])
+	}
+
+	row, col := 0, 0
+	for i := range n.Children {
+		child := &n.Children[i]
+		if explicit(child) {
+			continue
+		}
+		rs, cs := span(child)
+		for {
+			if col+cs > cols {
+				row, col = row+1, 0
+				continue
+			}
+			c := gridCell{row, col, row + rs, col + cs}
+			if free(c) {
+				cells[i] = c
+				mark(c)
+				col += cs
+				break
+			}
+			col++
+		}
+	}
+
+	rows = len(n.Props.GridRows)
+	for _, c := range cells {
+		rows = max(rows, c.rowEnd)
+	}
+	return cells, rows, cols
+}
+
+// sizeTracks resolves count track sizes within avail cells. Tracks beyond the
+// defined ones are Auto. When minContent is set, flexible tracks never shrink
+// below their content.
+func sizeTracks(defs []node.Track, count, avail, gap int, minContent bool, content func(i int) int) []int {
+	sizes := make([]int, count)
+	used := gap * max(count-1, 0)
+	totalFr := 0
+	for i := range sizes {
+		def := node.Auto()
+		if i < len(defs) {
+			def = defs[i]
+		}
+		switch def.Kind {
+		case node.TrackFixed:
+			sizes[i] = def.Size
+		case node.TrackAuto:
+			sizes[i] = content(i)
+		case node.TrackFr:
+			totalFr += def.Size
+			continue
+		}
+		used += sizes[i]
+	}
+
+	remaining := max(avail-used, 0)
+	for i := range sizes {
+		if i >= len(defs) || defs[i].Kind != node.TrackFr {
+			continue
+		}
+		if totalFr > 0 {
+			sizes[i] = (remaining * defs[i].Size) / totalFr
+		}
+		if minContent {
+			sizes[i] = max(sizes[i], content(i))
+		}
+	}
+	return sizes
+}
+
+// trackStarts returns the start coordinate of each track.
+func trackStarts(origin int, sizes []int, gap int) []int {
+	starts := make([]int, len(sizes))
+	pos := origin
+	for i, s := range sizes {
+		starts[i] = pos
+		pos += s + gap
+	}
+	return starts
+}
+
+// resolveLayers positions every layer placeholder found in the tree, including
+// layers nested inside other layers, and returns them in paint order.
+func (e *Engine) resolveLayers(root LayoutNode, screen Rect) []LayoutNode {
+	var pending []node.Node
+	collectLayers(root, &pending)
+	if len(pending) == 0 {
+		return nil
+	}
+
+	layers := make([]LayoutNode, 0, len(pending))
+	for i := 0; i < len(pending); i++ {
+		ref := screen
+		if key := pending[i].Props.Anchor; key != "" {
+			tree := root
+			tree.Layers = layers
+			if anchor, ok := Find(tree, key); ok {
+				ref = anchor.Rect
+			}
+		}
+		l := e.layoutLayer(&pending[i], ref, screen)
+		for _, c := range l.Children {
+			collectLayers(c, &pending)
+		}
+		layers = append(layers, l)
+	}
+
+	sort.SliceStable(layers, func(i, j int) bool {
+		return layers[i].Node.Props.ZIndex < layers[j].Node.Props.ZIndex
+	})
+	return layers
+}
+
+func collectLayers(ln LayoutNode, out *[]node.Node) {
+	if ln.Node.Type == node.LayerNode {
+		*out = append(*out, ln.Node)
+		return
+	}
+	for _, c := range ln.Children {
+		collectLayers(c, out)
+	}
+}
+
+// layoutLayer sizes a layer from its explicit size or its content, places it
+// against ref and keeps it on screen.
+func (e *Engine) layoutLayer(n *node.Node, ref, screen Rect) LayoutNode {
+	ln := LayoutNode{Node: *n}
+	if len(n.Children) == 0 {
+		return ln
+	}
+	child := &n.Children[0]
+
+	w := n.Props.Width
+	if w <= 0 {
+		w = e.measureWidth(child, screen)
+	}
+	w = min(w, screen.W)
+	h := n.Props.Height
+	if h <= 0 {
+		h = e.measureHeight(child, Rect{screen.X, screen.Y, w, screen.H})
+	}
+	h = min(h, screen.H)
+
+	var x, y int
+	switch n.Props.Placement {
+	case node.PlaceCenter:
+		x, y = ref.X+(ref.W-w)/2, ref.Y+(ref.H-h)/2
+	case node.PlaceTopLeft:
+		x, y = ref.X, ref.Y
+	case node.PlaceTopRight:
+		x, y = ref.X+ref.W-w, ref.Y
+	case node.PlaceBottomLeft:
+		x, y = ref.X, ref.Y+ref.H-h
+	case node.PlaceBottomRight:
+		x, y = ref.X+ref.W-w, ref.Y+ref.H-h
+	case node.PlaceBelow:
+		x, y = ref.X, ref.Y+ref.H
+		// Flip above the anchor when there is no room below
+		if y+h > screen.Y+screen.H && ref.Y-h >= screen.Y {
+			y = ref.Y - h
+		}
+	case node.PlaceAbove:
+		x, y = ref.X, ref.Y-h
+		if y < screen.Y && ref.Y+ref.H+h <= screen.Y+screen.H {
+			y = ref.Y + ref.H
+		}
+	}
+	x += n.Props.OffsetX
+	y += n.Props.OffsetY
+
+	// Keep the layer on screen
+	x = max(screen.X, min(x, screen.X+screen.W-w))
+	y = max(screen.Y, min(y, screen.Y+screen.H-h))
+
+	ln.Rect = Rect{x, y, w, h}
+	ln.Children = []LayoutNode{e.layout(child, ln.Rect)}
+	return ln
+}
+
+// measureWidthUncached computes the intrinsic width of a non-flex node.
+func (e *Engine) measureWidthUncached(n *node.Node, avail Rect) int {
+	if n.Type == node.LayerNode {
+		return 0
+	}
 	if n.Props.Width > 0 {
 		return n.Props.Width
 	}
 	switch n.Type {
 	case node.TextNode:
-		return utf8.RuneCountInString(n.Props.Text)
+		return textWidth(n.Props.Text)
 	case node.BoxNode:
+		_, right, _, left := boxInsets(n)
+		w := 0
 		if len(n.Children) > 0 {
-			return measureWidth(n.Children[0], avail) + 2
+			w = e.measureWidth(&n.Children[0], avail)
 		}
-		return 2
+		// Labels in the border need a space, a border rune and a corner
+		// on either side
+		for _, label := range []string{n.Props.Title, n.Props.Footer} {
+			if label != "" {
+				w = max(w, textWidth(label)+6-left-right)
+			}
+		}
+		return w + left + right
 	case node.RowNode:
-		w := 0
-		for _, c := range n.Children {
-			w += measureWidth(c, avail)
+		w := n.Props.ColGap * max(len(n.Children)-1, 0)
+		for i := range n.Children {
+			c := &n.Children[i]
+			w += e.measureWidth(c, avail)
 		}
 		return w
+	case node.GridNode:
+		return e.resolveGrid(n, avail, 0).width()
 	default:
 		return avail.W
 	}
 }
 
-// measureHeight returns the intrinsic height of a non-flex node.
-func measureHeight(n node.Node, avail Rect) int {
+// measureHeightUncached computes the intrinsic height of a non-flex node.
+func (e *Engine) measureHeightUncached(n *node.Node, avail Rect) int {
+	if n.Type == node.LayerNode {
+		return 0
+	}
 	if n.Props.Height > 0 {
 		return n.Props.Height
 	}
 	switch n.Type {
 	case node.TextNode:
-		lines := wrapText(n.Props.Text, avail.W)
-		return len(lines)
+		w := avail.W
+		if n.Props.Width > 0 {
+			w = min(w, n.Props.Width)
+		}
+		return len(e.textLines(n, w))
 	case node.BoxNode:
+		top, _, bottom, _ := boxInsets(n)
 		if len(n.Children) > 0 {
-			innerAvail := Rect{X: avail.X, Y: avail.Y, W: avail.W - 2, H: avail.H}
-			if innerAvail.W < 0 {
-				innerAvail.W = 0
-			}
-			return measureHeight(n.Children[0], innerAvail) + 2
+			return e.measureHeight(&n.Children[0], boxInner(n, avail)) + top + bottom
 		}
-		return 2
+		return top + bottom
 	case node.ColumnNode, node.ListNode, node.PaneNode:
 		h := 0
-		for _, c := range n.Children {
-			h += measureHeight(c, avail)
+		area := columnArea(n, avail)
+		for i := range n.Children {
+			c := &n.Children[i]
+			h += e.measureHeight(c, area)
 		}
 		return h
+	case node.RowNode:
+		// A row is as tall as its tallest child at the width it will get;
+		// a wrapping row stacks its lines
+		if !n.Props.FlexWrap {
+			return e.rowLine(n, 0, len(n.Children), avail).h
+		}
+		h := 0
+		for _, line := range e.rowLines(n, avail) {
+			h += line.h + n.Props.RowGap
+		}
+		return max(h-n.Props.RowGap, 1)
+	case node.GridNode:
+		return e.resolveGrid(n, avail, 0).height()
+	case node.VirtualListNode:
+		return e.measureVirtual(n, avail)
 	default:
 		return 1
 	}
@@ -266,47 +850,66 @@ func shiftY(ln *LayoutNode, dy int) {
 	}
 }
 
-func flexWeight(n node.Node) int {
-	return n.Props.FlexWeight
+// shiftX recursively shifts a layout node and all descendants by dx.
+func shiftX(ln *LayoutNode, dx int) {
+	ln.Rect.X += dx
+	for i := range ln.Children {
+		shiftX(&ln.Children[i], dx)
+	}
 }
 
-// wrapText wraps text to fit within maxWidth columns.
-func wrapText(s string, maxWidth int) []string {
-	if maxWidth <= 0 {
-		return nil
+// scrollChildrenX pans a container's children left by offset, clamped so the
+// content never scrolls past its right edge (maxOffset). It returns the
+// offset applied.
+func scrollChildrenX(ln *LayoutNode, offset, maxOffset int) int {
+	offset = min(offset, maxOffset)
+	if offset <= 0 {
+		return 0
 	}
-	if s == "" {
-		return []string{""}
+	for i := range ln.Children {
+		shiftX(&ln.Children[i], -offset)
 	}
+	return offset
+}
 
-	var lines []string
-	for _, paragraph := range strings.Split(s, "\n") {
-		trimmed := strings.TrimLeft(paragraph, " \t")
-		leading := paragraph[:len(paragraph)-len(trimmed)]
-
-		if trimmed == "" {
-			lines = append(lines, leading)
-			continue
-		}
-		words := strings.Fields(trimmed)
-		if len(words) == 0 {
-			lines = append(lines, leading)
-			continue
-		}
-		line := leading + words[0]
-		lineLen := utf8.RuneCountInString(line)
-		for _, w := range words[1:] {
-			wLen := utf8.RuneCountInString(w)
-			if lineLen+1+wLen <= maxWidth {
-				line += " " + w
-				lineLen += 1 + wLen
-			} else {
-				lines = append(lines, line)
-				line = leading + w
-				lineLen = utf8.RuneCountInString(leading) + wLen
-			}
+// justifyOffset returns how far child i of count is pushed along the main
+// axis when free cells are distributed according to j.
+func justifyOffset(j node.Justify, free, i, count int) int {
+	if free <= 0 {
+		return 0
+	}
+	switch j {
+	case node.JustifyCenter:
+		return free / 2
+	case node.JustifyEnd:
+		return free
+	case node.JustifySpaceBetween:
+		if count < 2 {
+			return 0
 		}
-		lines = append(lines, line)
+		return i * free / (count - 1)
+	case node.JustifySpaceAround:
+		return (2*i + 1) * free / (2 * count)
+	case node.JustifySpaceEvenly:
+		return (i + 1) * free / (count + 1)
 	}
-	return lines
+	return 0
+}
+
+// alignOffset returns the cross-axis offset of a child leaving free cells.
+func alignOffset(a node.Align, free int) int {
+	if free <= 0 {
+		return 0
+	}
+	switch a {
+	case node.AlignCenter:
+		return free / 2
+	case node.AlignEnd:
+		return free
+	}
+	return 0
+}
+
+func flexWeight(n *node.Node) int {
+	return n.Props.FlexWeight
 }
@@ -126,3 +126,291 @@ func TestExplicitSize(t *testing.T) {
 		t.Fatalf("expected width 10, got %d", ln.Rect.W)
 	}
 }
+
+func TestRowJustify(t *testing.T) {
+	tests := []struct {
+		justify node.Justify
+		xs      []int
+	}{
+		{node.JustifyStart, []int{0, 2}},
+		{node.JustifyCenter, []int{3, 5}},
+		{node.JustifyEnd, []int{6, 8}},
+		{node.JustifySpaceBetween, []int{0, 8}},
+		{node.JustifySpaceAround, []int{1, 6}},
+		{node.JustifySpaceEvenly, []int{2, 6}},
+	}
+	for _, tt := range tests {
+		n := node.Row(node.Text("ab"), node.Text("cd")).WithJustify(tt.justify)
+		ln := Layout(n, 10, 1)
+		for i, want := range tt.xs {
+			if got := ln.Children[i].Rect.X; got != want {
+				t.Errorf("justify %d child %d: expected x=%d, got %d", tt.justify, i, want, got)
+			}
+		}
+	}
+}
+
+func TestRowAlign(t *testing.T) {
+	n := node.Row(node.Text("a"), node.Text("b")).WithAlign(node.AlignEnd)
+	ln := Layout(n, 10, 5)
+	c := ln.Children[0]
+	if c.Rect.Y != 4 || c.Rect.H != 1 {
+		t.Fatalf("expected child at y=4 h=1, got y=%d h=%d", c.Rect.Y, c.Rect.H)
+	}
+}
+
+func TestColumnJustifyAndAlign(t *testing.T) {
+	n := node.Column(node.Text("dialog")).
+		WithJustify(node.JustifyCenter).
+		WithAlign(node.AlignCenter)
+	ln := Layout(n, 20, 11)
+	c := ln.Children[0]
+	if c.Rect.X != 7 || c.Rect.Y != 5 || c.Rect.W != 6 {
+		t.Fatalf("expected centered at (7,5) w=6, got (%d,%d) w=%d", c.Rect.X, c.Rect.Y, c.Rect.W)
+	}
+}
+
+func TestLayerCentered(t *testing.T) {
+	n := node.Column(
+		node.Text("content"),
+		node.Modal(node.Text("sure?")).WithSize(10, 3),
+	)
+	ln := Layout(n, 20, 11)
+	if len(ln.Layers) != 1 {
+		t.Fatalf("expected 1 layer, got %d", len(ln.Layers))
+	}
+	if ln.Children[1].Rect.W != 0 || ln.Children[1].Rect.H != 0 {
+		t.Fatalf("layer placeholder should take no space, got %+v", ln.Children[1].Rect)
+	}
+	r := ln.Layers[0].Rect
+	if r != (Rect{5, 4, 10, 3}) {
+		t.Fatalf("expected layer at {5 4 10 3}, got %+v", r)
+	}
+}
+
+func TestLayerAnchoredBelowFlips(t *testing.T) {
+	n := node.Column(
+		node.Spacer(),
+		node.Text("input").WithKey("in"),
+		node.Layer("in", node.PlaceBelow, node.Text("suggestion")).WithSize(10, 2),
+	)
+	ln := Layout(n, 20, 10)
+	r := ln.Layers[0].Rect
+	// The anchor sits on the last row, so the popup flips above it
+	if r.X != 0 || r.Y != 7 {
+		t.Fatalf("expected popup at (0,7), got (%d,%d)", r.X, r.Y)
+	}
+}
+
+func TestLayerZOrder(t *testing.T) {
+	n := node.Column(
+		node.Layer("", node.PlaceCenter, node.Text("top")).WithZIndex(2),
+		node.Layer("", node.PlaceCenter, node.Text("bottom")).WithZIndex(1),
+	)
+	ln := Layout(n, 20, 10)
+	if ln.Layers[0].Node.Props.ZIndex != 1 || ln.Layers[1].Node.Props.ZIndex != 2 {
+		t.Fatal("expected layers sorted by z-index")
+	}
+}
+
+func TestGridTracks(t *testing.T) {
+	n := node.Grid([]node.Track{node.Fixed(4), node.Auto(), node.Fr(1)},
+		node.Text("cpu"), node.Text("42%"), node.Text("bar"),
+		node.Text("memory"), node.Text("1.5G"), node.Text("bar"),
+	).WithGap(0, 1)
+	ln := Layout(n, 30, 10)
+	// cols: fixed 4, auto = max("42%","1.5G") = 4, fr = 30 - 4 - 4 - 2 gaps = 20
+	// "memory" is broken over two lines in the fixed column
+	want := []Rect{
+		{0, 0, 4, 1}, {5, 0, 4, 1}, {10, 0, 20, 1},
+		{0, 1, 4, 2}, {5, 1, 4, 1}, {10, 1, 20, 1},
+	}
+	for i, r := range want {
+		if got := ln.Children[i].Rect; got != r {
+			t.Errorf("child %d: expected %+v, got %+v", i, r, got)
+		}
+	}
+}
+
+func TestGridSpanAndPlacement(t *testing.T) {
+	n := node.Grid([]node.Track{node.Fixed(5), node.Fixed(5)},
+		node.Text("header").WithSpan(1, 2),
+		node.Text("b").WithGridCell(3, 2),
+		node.Text("a"),
+	).WithRows(node.Fixed(1), node.Fixed(2))
+	ln := Layout(n, 20, 10)
+	if got := ln.Children[0].Rect; got != (Rect{0, 0, 10, 1}) {
+		t.Fatalf("header: expected {0 0 10 1}, got %+v", got)
+	}
+	if got := ln.Children[1].Rect; got != (Rect{5, 3, 5, 1}) {
+		t.Fatalf("explicit: expected {5 3 5 1}, got %+v", got)
+	}
+	if got := ln.Children[2].Rect; got != (Rect{0, 1, 5, 1}) {
+		t.Fatalf("auto-placed: expected {0 1 5 1}, got %+v", got)
+	}
+	if h := NewEngine().measureHeight(&n, Rect{0, 0, 20, 10}); h != 4 {
+		t.Fatalf("expected grid height 4, got %d", h)
+	}
+}
+
+func TestRowHorizontalScroll(t *testing.T) {
+	n := node.Row(node.Text("aaaa"), node.Text("bbbb"), node.Text("cccc")).WithScrollX(3)
+	ln := Layout(n, 6, 1)
+	// Children keep natural width (12 total); offset 3 pans them left
+	if ln.Children[2].Rect.W != 4 {
+		t.Fatalf("expected unclamped width 4, got %d", ln.Children[2].Rect.W)
+	}
+	if ln.Children[0].Rect.X != -3 || ln.Children[1].Rect.X != 1 {
+		t.Fatalf("expected x=-3,1 got %d,%d", ln.Children[0].Rect.X, ln.Children[1].Rect.X)
+	}
+
+	// Offset clamps so content never scrolls past its right edge
+	ln = Layout(n.WithScrollX(100), 6, 1)
+	if ln.Children[2].Rect.X != 2 {
+		t.Fatalf("expected clamped x=2, got %d", ln.Children[2].Rect.X)
+	}
+}
+
+func TestColumnHorizontalScrollNoWrap(t *testing.T) {
+	n := node.Pane(
+		node.Text("a long log line that does not wrap").WithNoWrap(),
+		node.Text("short").WithNoWrap(),
+	).WithScroll(5, 0)
+	ln := Layout(n, 10, 5)
+	first := ln.Children[0]
+	if first.Rect.H != 1 {
+		t.Fatalf("expected no-wrap text to stay 1 line, got %d", first.Rect.H)
+	}
+	if first.Rect.W != 34 || first.Rect.X != -5 {
+		t.Fatalf("expected natural width 34 at x=-5, got w=%d x=%d", first.Rect.W, first.Rect.X)
+	}
+}
+
+func TestTextHorizontalScroll(t *testing.T) {
+	n := node.Text("0123456789\nabc").WithKey("log").WithNoWrap().WithScrollX(100)
+	m, ok := ScrollMetricsByKey(Layout(n, 4, 5))["log"]
+	if !ok {
+		t.Fatal("expected scroll metrics for horizontally scrolled text")
+	}
+	want := ScrollMetrics{ContentW: 10, ContentH: 2, ViewportW: 4, ViewportH: 2, OffsetX: 6}
+	if m != want {
+		t.Fatalf("expected %+v, got %+v", want, m)
+	}
+}
+
+func TestRowHeightWithWrappingFlexChild(t *testing.T) {
+	n := node.Column(
+		node.Row(
+			node.Text("• "),
+			node.RichText(node.Span{Text: "one two three four"}).WithFlex(1),
+		),
+		node.Text("next"),
+	)
+	ln := Layout(n, 12, 10)
+	// The paragraph wraps in 10 cells: "one two" / "three four"
+	if ln.Children[0].Rect.H != 2 {
+		t.Fatalf("expected row height 2, got %d", ln.Children[0].Rect.H)
+	}
+	if ln.Children[1].Rect.Y != 2 {
+		t.Fatalf("expected next line at y=2, got %d", ln.Children[1].Rect.Y)
+	}
+}
+
+func TestScrollMetricsClamped(t *testing.T) {
+	var lines []node.Node
+	for i := 0; i < 10; i++ {
+		lines = append(lines, node.Text("line"))
+	}
+	tree := node.Column(
+		node.Column(lines...).WithKey("log").WithScrollOffset(50).WithScrollbar(),
+	)
+	ln := Layout(tree, 20, 4)
+	m, ok := ScrollMetricsByKey(ln)["log"]
+	if !ok {
+		t.Fatal("expected metrics for log")
+	}
+	want := ScrollMetrics{ContentW: 19, ContentH: 10, ViewportW: 19, ViewportH: 4, OffsetY: 6}
+	if m != want {
+		t.Fatalf("expected %+v, got %+v", want, m)
+	}
+	if got := ln.Children[0].Children[9].Rect; got != (Rect{0, 3, 19, 1}) {
+		t.Fatalf("expected last line at the bottom, got %+v", got)
+	}
+}
+
+func TestScrollMetricsToBottom(t *testing.T) {
+	var lines []node.Node
+	for i := 0; i < 10; i++ {
+		lines = append(lines, node.Text("line"))
+	}
+	tree := node.Column(lines...).WithKey("log").WithScrollToBottom().WithScrollOffset(99)
+	m := ScrollMetricsByKey(Layout(tree, 20, 4))["log"]
+	if m.OffsetY != 0 || m.MaxOffsetY() != 6 {
+		t.Fatalf("expected offset 0 of max 6, got %d of %d", m.OffsetY, m.MaxOffsetY())
+	}
+}
+
+func TestBoxMeasureFitsTitle(t *testing.T) {
+	box := node.Box(node.BorderSingle, node.Text("ab")).WithTitle("Title", node.TextAlignLeft)
+	ln := Layout(node.Row(box), 40, 5)
+	if w := ln.Children[0].Rect.W; w != 11 {
+		t.Fatalf("expected box wide enough for its title (11), got %d", w)
+	}
+}
+
+func TestRowFlexShrink(t *testing.T) {
+	n := node.Row(
+		node.Text("title-title").WithFlexShrink(1), // 11 wide
+		node.Text("[save]"),
+		node.Text("[quit]"),
+	)
+	ln := Layout(n, 16, 1)
+	// 23 cells of content in 16: the title gives up the 7 cell overflow
+	want := []Rect{{0, 0, 4, 1}, {4, 0, 6, 1}, {10, 0, 6, 1}}
+	for i, r := range want {
+		if got := ln.Children[i].Rect; got != r {
+			t.Fatalf("child %d: expected %+v, got %+v", i, r, got)
+		}
+	}
+}
+
+func TestRowFlexShrinkProportional(t *testing.T) {
+	n := node.Row(
+		node.Text("aaaaaaaaaa").WithFlexShrink(1),
+		node.Text("bbbbbbbbbb").WithFlexShrink(3),
+	)
+	ln := Layout(n, 12, 1)
+	if a, b := ln.Children[0].Rect.W, ln.Children[1].Rect.W; a != 8 || b != 4 {
+		t.Fatalf("expected widths 8 and 4, got %d and %d", a, b)
+	}
+}
+
+func TestRowFlexBasis(t *testing.T) {
+	n := node.Row(
+		node.Text("x").WithFlex(1).WithFlexBasis(10),
+		node.Text("y").WithFlex(1),
+	)
+	ln := Layout(n, 20, 1)
+	if a, b := ln.Children[0].Rect.W, ln.Children[1].Rect.W; a != 15 || b != 5 {
+		t.Fatalf("expected widths 15 and 5, got %d and %d", a, b)
+	}
+}
+
+func TestRowFlexWrap(t *testing.T) {
+	chip := func(s string) node.Node { return node.Text("[" + s + "]") }
+	n := node.Column(
+		node.Row(chip("go"), chip("rust"), chip("zig"), chip("c")).WithFlexWrap().WithGap(1, 1),
+		node.Text("below"),
+	)
+	ln := Layout(n, 12, 10)
+	row := ln.Children[0]
+	want := []Rect{{0, 0, 4, 1}, {5, 0, 6, 1}, {0, 2, 5, 1}, {6, 2, 3, 1}}
+	for i, r := range want {
+		if got := row.Children[i].Rect; got != r {
+			t.Fatalf("chip %d: expected %+v, got %+v", i, r, got)
+		}
+	}
+	if got := ln.Children[1].Rect.Y; got != 3 {
+		t.Fatalf("expected text below the wrapped row at y=3, got %d", got)
+	}
+}
@@ -0,0 +1,46 @@
+package layout
+
+// ScrollMetrics describes a scrollable node after layout: the size of its
+// content, the size of the viewport showing it, and the scroll offsets that
+// were actually applied after clamping. Offsets count from the top left of
+// the content, whatever the node's scroll mode.
+type ScrollMetrics struct {
+	ContentW, ContentH   int
+	ViewportW, ViewportH int
+	OffsetX, OffsetY     int
+}
+
+// MaxOffsetY returns the largest vertical offset that still fills the viewport.
+func (m ScrollMetrics) MaxOffsetY() int {
+	return max(m.ContentH-m.ViewportH, 0)
+}
+
+// MaxOffsetX returns the largest horizontal offset that still fills the viewport.
+func (m ScrollMetrics) MaxOffsetX() int {
+	return max(m.ContentW-m.ViewportW, 0)
+}
+
+// Overflows reports whether the content is taller than the viewport.
+func (m ScrollMetrics) Overflows() bool {
+	return m.ContentH > m.ViewportH
+}
+
+// ScrollMetricsByKey returns the scroll metrics of every keyed scrollable
+// node in the tree, including those inside layers.
+func ScrollMetricsByKey(tree LayoutNode) map[string]ScrollMetrics {
+	out := map[string]ScrollMetrics{}
+	collectScroll(tree, out)
+	for _, l := range tree.Layers {
+		collectScroll(l, out)
+	}
+	return out
+}
+
+func collectScroll(ln LayoutNode, out map[string]ScrollMetrics) {
+	if ln.Scroll != nil && ln.Node.Props.Key != "" {
+		out[ln.Node.Props.Key] = *ln.Scroll
+	}
+	for _, c := range ln.Children {
+		collectScroll(c, out)
+	}
+}
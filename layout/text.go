@@ -0,0 +1,198 @@
+package layout
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/stukennedy/tooey/node"
+)
+
+// Line is one display line of a Text node. Src maps each rune back to its
+// index in the source text's runes, or -1 for inserted runes such as "…".
+type Line struct {
+	Runes []rune
+	Src   []int
+}
+
+// String returns the line's text.
+func (l Line) String() string {
+	return string(l.Runes)
+}
+
+// Width returns the number of cells the line occupies.
+func (l Line) Width() int {
+	return len(l.Runes)
+}
+
+func (l *Line) push(r rune, src int) {
+	l.Runes = append(l.Runes, r)
+	l.Src = append(l.Src, src)
+}
+
+// pushRange appends runes[from:to] with their source indices.
+func (l *Line) pushRange(runes []rune, from, to int) {
+	for i := from; i < to; i++ {
+		l.push(runes[i], i)
+	}
+}
+
+// TextLines breaks a Text node into display lines at the given width,
+// honoring its overflow mode. Layout measures and paint draws with the same
+// lines, so heights always match what ends up on screen.
+func TextLines(n node.Node, width int) []Line {
+	return WrapText(n.Props.Text, width, n.Props.Overflow)
+}
+
+// WrapText breaks s into display lines of at most width cells. Explicit
+// newlines always start a new line; what happens to longer lines depends on
+// mode. A width of zero or less yields no lines.
+func WrapText(s string, width int, mode node.Overflow) []Line {
+	if width <= 0 {
+		return nil
+	}
+	runes := []rune(s)
+	var lines []Line
+	start := 0
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && runes[i] != '\n' {
+			continue
+		}
+		lines = breakParagraph(lines, runes, start, i, width, mode)
+		start = i + 1
+	}
+	return lines
+}
+
+// breakParagraph appends the display lines for runes[start:end], a single
+// paragraph without newlines.
+func breakParagraph(lines []Line, runes []rune, start, end, width int, mode node.Overflow) []Line {
+	n := end - start
+	var l Line
+	switch mode {
+	case node.OverflowClip:
+		l.pushRange(runes, start, end)
+		return append(lines, l)
+
+	case node.OverflowWrapChar:
+		if n == 0 {
+			return append(lines, l)
+		}
+		for from := start; from < end; from += width {
+			var chunk Line
+			chunk.pushRange(runes, from, min(from+width, end))
+			lines = append(lines, chunk)
+		}
+		return lines
+
+	case node.OverflowEllipsis, node.OverflowEllipsisStart, node.OverflowEllipsisMiddle:
+		if n <= width {
+			l.pushRange(runes, start, end)
+			return append(lines, l)
+		}
+		keep := width - 1
+		switch mode {
+		case node.OverflowEllipsis:
+			l.pushRange(runes, start, start+keep)
+			l.push('…', -1)
+		case node.OverflowEllipsisStart:
+			l.push('…', -1)
+			l.pushRange(runes, end-keep, end)
+		default:
+			tail := keep / 2
+			l.pushRange(runes, start, start+keep-tail)
+			l.push('…', -1)
+			l.pushRange(runes, end-tail, end)
+		}
+		return append(lines, l)
+	}
+
+	return wrapWords(lines, runes, start, end, width)
+}
+
+// wrapWords word-wraps runes[start:end]. Leading indentation is repeated on
+// continuation lines, runs of whitespace between words collapse to a single
+// space, and words longer than the line are broken across lines. An indent
+// as wide as the line or wider is dropped.
+func wrapWords(lines []Line, runes []rune, start, end, width int) []Line {
+	isBlank := func(r rune) bool { return r == ' ' || r == '\t' }
+	indentEnd := start
+	for indentEnd < end && isBlank(runes[indentEnd]) {
+		indentEnd++
+	}
+	indentStart := start
+	if indentEnd-start >= width {
+		indentStart = indentEnd
+	}
+
+	newLine := func() Line {
+		var l Line
+		l.pushRange(runes, indentStart, indentEnd)
+		return l
+	}
+	cur := newLine()
+	hasWord := false
+	sep := -1 // source index of the whitespace before the next word
+
+	i := indentEnd
+	for i < end {
+		if unicode.IsSpace(runes[i]) {
+			if sep < 0 {
+				sep = i
+			}
+			i++
+			continue
+		}
+		wordStart := i
+		for i < end && !unicode.IsSpace(runes[i]) {
+			i++
+		}
+		wordLen := i - wordStart
+
+		if hasWord && cur.Width()+1+wordLen <= width {
+			cur.push(' ', sep)
+			cur.pushRange(runes, wordStart, i)
+			sep = -1
+			continue
+		}
+		if hasWord {
+			lines = append(lines, cur)
+			cur = newLine()
+		}
+		// Break words that cannot fit on a line of their own
+		from := wordStart
+		for from < i && cur.Width()+(i-from) > width {
+			room := max(width-cur.Width(), 1)
+			cur.pushRange(runes, from, from+room)
+			from += room
+			lines = append(lines, cur)
+			cur = newLine()
+		}
+		cur.pushRange(runes, from, i)
+		hasWord = true
+		sep = -1
+	}
+	return append(lines, cur)
+}
+
+// wrapText wraps text to fit within maxWidth columns.
+func wrapText(s string, maxWidth int) []string {
+	lines := WrapText(s, maxWidth, node.OverflowWrap)
+	if lines == nil {
+		return nil
+	}
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = l.String()
+	}
+	return out
+}
+
+// textWidth returns the width of the longest line in s.
+func textWidth(s string) int {
+	w := 0
+	for _, line := range strings.Split(s, "\n") {
+		w = max(w, utf8.RuneCountInString(line))
+	}
+	return w
+}
@@ -0,0 +1,103 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/stukennedy/tooey/node"
+)
+
+func lineStrings(lines []Line) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = l.String()
+	}
+	return out
+}
+
+func TestWrapTextModes(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		mode node.Overflow
+		want []string
+	}{
+		{"word", "hello big world", node.OverflowWrap, []string{"hello", "big", "world"}},
+		{"word breaks long words", "see 0123456789abc", node.OverflowWrap, []string{"see", "012345", "6789ab", "c"}},
+		{"word keeps indent", "  ab cd ef", node.OverflowWrap, []string{"  ab", "  cd", "  ef"}},
+		{"char", "hello world", node.OverflowWrapChar, []string{"hello ", "world"}},
+		{"clip", "hello world\nx", node.OverflowClip, []string{"hello world", "x"}},
+		{"ellipsis", "src/app/main.go", node.OverflowEllipsis, []string{"src/a…"}},
+		{"ellipsis start", "src/app/main.go", node.OverflowEllipsisStart, []string{"…in.go"}},
+		{"ellipsis middle", "src/app/main.go", node.OverflowEllipsisMiddle, []string{"src…go"}},
+		{"ellipsis fits", "short", node.OverflowEllipsisMiddle, []string{"short"}},
+	}
+	for _, tt := range tests {
+		got := lineStrings(WrapText(tt.text, 6, tt.mode))
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestWrapTextWideIndent(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  []string
+	}{
+		{"      ab", 3, []string{"ab"}},
+		{"      abcd", 3, []string{"abc", "d"}},
+		{"   ab cd", 3, []string{"ab", "cd"}},
+	}
+	for _, tt := range tests {
+		got := lineStrings(WrapText(tt.text, tt.width, node.OverflowWrap))
+		if len(got) != len(tt.want) {
+			t.Errorf("%q at %d: expected %q, got %q", tt.text, tt.width, tt.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%q at %d: expected %q, got %q", tt.text, tt.width, tt.want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestWrapTextSourceIndices(t *testing.T) {
+	lines := WrapText("ab   cd", 10, node.OverflowWrap)
+	if len(lines) != 1 || lines[0].String() != "ab cd" {
+		t.Fatalf("expected collapsed %q, got %q", "ab cd", lineStrings(lines))
+	}
+	want := []int{0, 1, 2, 5, 6}
+	for i, src := range lines[0].Src {
+		if src != want[i] {
+			t.Fatalf("expected src %v, got %v", want, lines[0].Src)
+		}
+	}
+
+	lines = WrapText("abcdef", 4, node.OverflowEllipsis)
+	if lines[0].Src[3] != -1 {
+		t.Fatalf("expected inserted ellipsis to have src -1, got %v", lines[0].Src)
+	}
+}
+
+func TestMeasureHeightMatchesOverflow(t *testing.T) {
+	text := "one two three four"
+	wrapped := node.Text(text)
+	clipped := node.Text(text).WithOverflow(node.OverflowEllipsisMiddle)
+	avail := Rect{0, 0, 8, 10}
+	if h := NewEngine().measureHeight(&wrapped, avail); h != 3 {
+		t.Fatalf("expected wrapped height 3, got %d", h)
+	}
+	if h := NewEngine().measureHeight(&clipped, avail); h != 1 {
+		t.Fatalf("expected ellipsis height 1, got %d", h)
+	}
+}
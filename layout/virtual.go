@@ -0,0 +1,147 @@
+package layout
+
+import (
+	"reflect"
+
+	"github.com/stukennedy/tooey/node"
+)
+
+// rowKey identifies a VirtualList row height across frames.
+type rowKey struct {
+	list  string
+	index int
+	width int
+}
+
+type cachedRow struct {
+	node node.Node
+	h    int
+}
+
+// virtualRows builds and measures the rows of a VirtualList on demand, at
+// most once each per frame.
+type virtualRows struct {
+	e     *Engine
+	list  *node.Node
+	width int
+	built map[int]*node.Node
+	h     map[int]int
+}
+
+func (v *virtualRows) row(i int) *node.Node {
+	if r, ok := v.built[i]; ok {
+		return r
+	}
+	r := new(node.Node)
+	*r = v.list.Props.Row(i)
+	v.built[i] = r
+	return r
+}
+
+// height returns the height of row i. Rows of keyed lists are remembered
+// across frames and reused while the row renders to the same node.
+func (v *virtualRows) height(i int) int {
+	if h, ok := v.h[i]; ok {
+		return h
+	}
+	r := v.row(i)
+	key := rowKey{v.list.Props.Key, i, v.width}
+	h := -1
+	if key.list != "" {
+		if c, ok := v.e.prevRows[key]; ok && reflect.DeepEqual(c.node, *r) {
+			h = c.h
+		}
+	}
+	if h < 0 {
+		h = v.e.measureHeight(r, Rect{W: v.width})
+	}
+	if key.list != "" {
+		v.e.rows[key] = cachedRow{node: *r, h: h}
+	}
+	v.h[i] = h
+	return h
+}
+
+// measureVirtual returns the height of the first rows of a VirtualList, up
+// to avail.H; rows past the viewport are never built. Without a height to
+// fill it assumes one line per row.
+func (e *Engine) measureVirtual(n *node.Node, avail Rect) int {
+	p := n.Props
+	area := columnArea(n, avail)
+	if avail.H <= 0 || p.Row == nil || area.W <= 0 {
+		return max(p.Items, 0)
+	}
+	rows := &virtualRows{e: e, list: n, width: area.W, built: map[int]*node.Node{}, h: map[int]int{}}
+	fill := 0
+	for i := 0; i < p.Items && fill < area.H; i++ {
+		fill += rows.height(i)
+	}
+	return fill
+}
+
+// layoutVirtual lays out only the rows of a VirtualList that are in view,
+// plus the overscan on either side. The first visible row is ScrollOffset,
+// clamped so the last page stays full and moved if needed to keep the
+// selected row in view. Scroll metrics count rows, not cells; ViewportH is
+// the number of rows fully visible at the applied offset.
+func (e *Engine) layoutVirtual(n *node.Node, avail Rect) LayoutNode {
+	ln := LayoutNode{Node: *n, Rect: avail}
+	p := n.Props
+	area := columnArea(n, avail)
+	ln.Scroll = &ScrollMetrics{ContentW: area.W, ViewportW: area.W}
+	if p.Items <= 0 || p.Row == nil || area.W <= 0 || area.H <= 0 {
+		return ln
+	}
+	rows := &virtualRows{e: e, list: n, width: area.W, built: map[int]*node.Node{}, h: map[int]int{}}
+
+	// The last page starts at the first row that still fills the viewport
+	maxTop, fill := p.Items, 0
+	for maxTop > 0 && fill+rows.height(maxTop-1) <= area.H {
+		maxTop--
+		fill += rows.height(maxTop)
+	}
+	maxTop = min(maxTop, p.Items-1)
+
+	top := max(0, min(p.ScrollOffset, maxTop))
+	if sel := p.Selected; sel >= 0 && sel < p.Items {
+		if sel < top {
+			top = sel
+		} else {
+			// Scroll down just far enough for the selected row to fit
+			first, fill := sel, rows.height(sel)
+			for first > top && fill+rows.height(first-1) <= area.H {
+				first--
+				fill += rows.height(first)
+			}
+			top = first
+		}
+	}
+
+	end := top
+	for y := area.Y; end < p.Items && y < area.Y+area.H; end++ {
+		y += rows.height(end)
+	}
+	start := max(top-p.Overscan, 0)
+	end = min(end+p.Overscan, p.Items)
+
+	y := area.Y
+	for i := start; i < top; i++ {
+		y -= rows.height(i)
+	}
+	for i := start; i < end; i++ {
+		h := rows.height(i)
+		ln.Children = append(ln.Children, e.layout(rows.row(i), Rect{area.X, y, area.W, h}))
+		y += h
+	}
+
+	visible, fill := 0, 0
+	for i := top; i < p.Items && fill+rows.height(i) <= area.H; i++ {
+		fill += rows.height(i)
+		visible++
+	}
+
+	ln.Scroll.ContentH = p.Items
+	ln.Scroll.ViewportH = max(visible, 1)
+	ln.Scroll.OffsetY = top
+	return ln
+}
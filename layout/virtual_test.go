@@ -0,0 +1,120 @@
+package layout
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stukennedy/tooey/node"
+)
+
+func countingRows(built *[]int) func(i int) node.Node {
+	return func(i int) node.Node {
+		*built = append(*built, i)
+		return node.Text(fmt.Sprintf("row %d", i))
+	}
+}
+
+func TestVirtualListBuildsVisibleRows(t *testing.T) {
+	var built []int
+	list := node.VirtualList(200000, countingRows(&built)).WithKey("pkgs").WithScrollOffset(1000).WithOverscan(2)
+	ln := Layout(list, 20, 5)
+
+	if len(ln.Children) != 9 {
+		t.Fatalf("expected 5 visible + 4 overscan rows, got %d", len(ln.Children))
+	}
+	if got := ln.Children[2]; got.Node.Props.Text != "row 1000" || got.Rect.Y != 0 {
+		t.Fatalf("expected row 1000 at the top, got %q at y=%d", got.Node.Props.Text, got.Rect.Y)
+	}
+	// Only the viewport, overscan and the last page are ever built
+	if len(built) > 20 {
+		t.Fatalf("expected a handful of rows built, got %d", len(built))
+	}
+	m := ScrollMetricsByKey(ln)["pkgs"]
+	if m.OffsetY != 1000 || m.ContentH != 200000 || m.MaxOffsetY() != 199995 {
+		t.Fatalf("unexpected metrics %+v", m)
+	}
+}
+
+func TestVirtualListKeepsSelectionInView(t *testing.T) {
+	var built []int
+	rows := countingRows(&built)
+	list := node.VirtualList(100, rows).WithScrollOffset(0).WithSelected(30)
+	ln := Layout(list, 20, 5)
+	last := ln.Children[len(ln.Children)-1]
+	if last.Node.Props.Text != "row 30" || last.Rect.Y != 4 {
+		t.Fatalf("expected row 30 on the bottom line, got %q at y=%d", last.Node.Props.Text, last.Rect.Y)
+	}
+
+	list = node.VirtualList(100, rows).WithScrollOffset(50).WithSelected(10)
+	if first := Layout(list, 20, 5).Children[0]; first.Node.Props.Text != "row 10" || first.Rect.Y != 0 {
+		t.Fatalf("expected row 10 at the top, got %q at y=%d", first.Node.Props.Text, first.Rect.Y)
+	}
+}
+
+func TestVirtualListVariableHeights(t *testing.T) {
+	rows := func(i int) node.Node {
+		if i%2 == 1 {
+			return node.Text("a long row that wraps")
+		}
+		return node.Text("short")
+	}
+	// Rows alternate 1 and 3 lines at width 8; scrolling past the end clamps
+	// to the last page, rows 8 and 9, as row 7 would not fit with them
+	ln := Layout(node.VirtualList(10, rows).WithScrollOffset(9), 8, 5)
+	if len(ln.Children) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(ln.Children))
+	}
+	if got := ln.Children[1].Rect; got != (Rect{0, 1, 8, 3}) {
+		t.Fatalf("expected row 9 below row 8 with height 3, got %+v", got)
+	}
+}
+
+func TestVirtualListViewportRows(t *testing.T) {
+	rows := func(i int) node.Node {
+		if i%2 == 1 {
+			return node.Text("a long row that wraps")
+		}
+		return node.Text("short")
+	}
+	// Rows 0-2 (1+3+1 lines) fill the viewport at the top; from row 1 only
+	// rows 1 and 2 fit
+	for _, tt := range []struct{ offset, want int }{{0, 3}, {1, 2}} {
+		ln := Layout(node.VirtualList(10, rows).WithKey("l").WithScrollOffset(tt.offset), 8, 5)
+		if m := ScrollMetricsByKey(ln)["l"]; m.ViewportH != tt.want {
+			t.Errorf("offset %d: expected %d visible rows, got %d", tt.offset, tt.want, m.ViewportH)
+		}
+	}
+
+	// A list that is not flexed is as tall as its rows
+	ln := Layout(node.Column(node.VirtualList(3, rows), node.Text("footer")), 8, 10)
+	if got := ln.Children[1].Rect.Y; got != 5 {
+		t.Fatalf("expected the footer below 5 lines of rows, got y=%d", got)
+	}
+}
+
+func TestVirtualListRowHeightCache(t *testing.T) {
+	var built []int
+	list := node.VirtualList(50, countingRows(&built)).WithKey("l")
+	e := NewEngine()
+	e.Layout(list, 20, 5)
+	if len(e.rows) == 0 || len(e.heights) == 0 {
+		t.Fatal("expected keyed row heights to be measured and cached")
+	}
+	// Unchanged rows are not measured again
+	e.Layout(list, 20, 5)
+	if len(e.heights) != 0 {
+		t.Fatalf("expected no measurements on the second frame, got %d", len(e.heights))
+	}
+}
+
+func TestVirtualListMeasuresPerHeight(t *testing.T) {
+	n := node.VirtualList(50, func(i int) node.Node { return node.Text("row") })
+	e := NewEngine()
+	if h := e.measureHeight(&n, Rect{0, 0, 10, 3}); h != 3 {
+		t.Fatalf("expected 3 rows to fill 3 lines, got %d", h)
+	}
+	// The same node with more room is measured again, not read from the cache
+	if h := e.measureHeight(&n, Rect{0, 0, 10, 8}); h != 8 {
+		t.Fatalf("expected 8 rows to fill 8 lines, got %d", h)
+	}
+}
@@ -82,9 +82,9 @@ func RenderWithColors(text string, width int, colors ColorScheme) []node.Node {
 		if level, content := parseHeading(line); level > 0 {
 			spans := parseInline(content, colors.Heading, colors)
 			for idx := range spans {
-				spans[idx].Props.Style |= node.Bold
+				spans[idx].Style |= node.Bold
 			}
-			nodes = append(nodes, node.Row(spans...))
+			nodes = append(nodes, node.RichText(spans...))
 			i++
 			continue
 		}
@@ -93,15 +93,10 @@ func RenderWithColors(text string, width int, colors ColorScheme) []node.Node {
 		if rest, checked := parseCheckbox(line); checked >= 0 {
 			if checked == 1 {
 				prefix := node.TextStyled("  ✔ ", colors.CheckOn, 0, 0)
-				spans := parseInline(rest, colors.Text, colors)
-				nodes = append(nodes, node.Row(append([]node.Node{prefix}, spans...)...))
+				nodes = append(nodes, hanging(prefix, parseInline(rest, colors.Text, colors), 0))
 			} else {
 				prefix := node.TextStyled("  ☐ ", colors.CheckOff, 0, 0)
-				spans := parseInline(rest, colors.Text, colors)
-				for idx := range spans {
-					spans[idx].Props.Style |= node.Dim
-				}
-				nodes = append(nodes, node.Row(append([]node.Node{prefix}, spans...)...))
+				nodes = append(nodes, hanging(prefix, parseInline(rest, colors.Text, colors), node.Dim))
 			}
 			i++
 			continue
@@ -110,8 +105,7 @@ func RenderWithColors(text string, width int, colors ColorScheme) []node.Node {
 		// Bullet list
 		if rest, ok := parseBullet(line); ok {
 			prefix := node.TextStyled("  • ", colors.Bullet, 0, 0)
-			spans := parseInline(rest, colors.Text, colors)
-			nodes = append(nodes, node.Row(append([]node.Node{prefix}, spans...)...))
+			nodes = append(nodes, hanging(prefix, parseInline(rest, colors.Text, colors), 0))
 			i++
 			continue
 		}
@@ -119,8 +113,7 @@ func RenderWithColors(text string, width int, colors ColorScheme) []node.Node {
 		// Numbered list
 		if num, rest, ok := parseNumbered(line); ok {
 			prefix := node.TextStyled("  "+num+". ", colors.Bullet, 0, 0)
-			spans := parseInline(rest, colors.Text, colors)
-			nodes = append(nodes, node.Row(append([]node.Node{prefix}, spans...)...))
+			nodes = append(nodes, hanging(prefix, parseInline(rest, colors.Text, colors), 0))
 			i++
 			continue
 		}
@@ -128,11 +121,7 @@ func RenderWithColors(text string, width int, colors ColorScheme) []node.Node {
 		// Blockquote
 		if rest, ok := parseBlockquote(line); ok {
 			prefix := node.TextStyled("  │ ", colors.Quote, 0, 0)
-			spans := parseInline(rest, colors.Text, colors)
-			for idx := range spans {
-				spans[idx].Props.Style |= node.Italic
-			}
-			nodes = append(nodes, node.Row(append([]node.Node{prefix}, spans...)...))
+			nodes = append(nodes, hanging(prefix, parseInline(rest, colors.Text, colors), node.Italic))
 			i++
 			continue
 		}
@@ -145,13 +134,20 @@ func RenderWithColors(text string, width int, colors ColorScheme) []node.Node {
 		}
 
 		// Plain paragraph
-		spans := parseInline(line, colors.Text, colors)
-		nodes = append(nodes, node.Row(spans...))
+		nodes = append(nodes, node.RichText(parseInline(line, colors.Text, colors)...))
 		i++
 	}
 	return nodes
 }
 
+// hanging returns prefix followed by the spans as one wrapping paragraph, so
+// continuation lines stay indented past the prefix.
+func hanging(prefix node.Node, spans []node.Span, style node.StyleFlags) node.Node {
+	body := node.RichText(spans...).WithFlex(1)
+	body.Props.Style = style
+	return node.Row(prefix, body)
+}
+
 func isHorizontalRule(s string) bool {
 	s = strings.ReplaceAll(s, " ", "")
 	if len(s) < 3 {
@@ -230,16 +226,16 @@ func parseBlockquote(line string) (string, bool) {
 	return "", false
 }
 
-// parseInline scans text for inline markdown and returns styled nodes.
-func parseInline(text string, defaultFG node.Color, colors ColorScheme) []node.Node {
-	var nodes []node.Node
+// parseInline scans text for inline markdown and returns styled spans.
+func parseInline(text string, defaultFG node.Color, colors ColorScheme) []node.Span {
+	var spans []node.Span
 	var buf strings.Builder
 	runes := []rune(text)
 	i := 0
 
 	flush := func(fg node.Color, style node.StyleFlags) {
 		if buf.Len() > 0 {
-			nodes = append(nodes, node.TextStyled(buf.String(), fg, 0, style))
+			spans = append(spans, node.Span{Text: buf.String(), FG: fg, Style: style})
 			buf.Reset()
 		}
 	}
@@ -249,7 +245,7 @@ func parseInline(text string, defaultFG node.Color, colors ColorScheme) []node.N
 		if i+2 < len(runes) && runes[i] == '*' && runes[i+1] == '*' && runes[i+2] == '*' {
 			if end := findClose(runes, i+3, "***"); end >= 0 {
 				flush(defaultFG, 0)
-				nodes = append(nodes, node.TextStyled(string(runes[i+3:end]), defaultFG, 0, node.Bold|node.Italic))
+				spans = append(spans, node.Span{Text: string(runes[i+3 : end]), FG: defaultFG, Style: node.Bold | node.Italic})
 				i = end + 3
 				continue
 			}
@@ -258,7 +254,7 @@ func parseInline(text string, defaultFG node.Color, colors ColorScheme) []node.N
 		if i+1 < len(runes) && runes[i] == '*' && runes[i+1] == '*' {
 			if end := findClose(runes, i+2, "**"); end >= 0 {
 				flush(defaultFG, 0)
-				nodes = append(nodes, node.TextStyled(string(runes[i+2:end]), defaultFG, 0, node.Bold))
+				spans = append(spans, node.Span{Text: string(runes[i+2 : end]), FG: defaultFG, Style: node.Bold})
 				i = end + 2
 				continue
 			}
@@ -272,7 +268,7 @@ func parseInline(text string, defaultFG node.Color, colors ColorScheme) []node.N
 		if runes[i] == '*' {
 			if end := findClose(runes, i+1, "*"); end >= 0 && end > i+1 {
 				flush(defaultFG, 0)
-				nodes = append(nodes, node.TextStyled(string(runes[i+1:end]), defaultFG, 0, node.Italic))
+				spans = append(spans, node.Span{Text: string(runes[i+1 : end]), FG: defaultFG, Style: node.Italic})
 				i = end + 1
 				continue
 			}
@@ -281,16 +277,16 @@ func parseInline(text string, defaultFG node.Color, colors ColorScheme) []node.N
 		if runes[i] == '`' {
 			if end := findClose(runes, i+1, "`"); end >= 0 {
 				flush(defaultFG, 0)
-				nodes = append(nodes, node.TextStyled(string(runes[i+1:end]), colors.Code, colors.CodeBG, 0))
+				spans = append(spans, node.Span{Text: string(runes[i+1 : end]), FG: colors.Code, BG: colors.CodeBG})
 				i = end + 1
 				continue
 			}
 		}
 		// Link [text](url)
 		if runes[i] == '[' {
-			if linkText, end := parseLink(runes, i); end >= 0 {
+			if linkText, url, end := parseLink(runes, i); end >= 0 {
 				flush(defaultFG, 0)
-				nodes = append(nodes, node.TextStyled(linkText, colors.Link, 0, node.Underline))
+				spans = append(spans, node.Span{Text: linkText, FG: colors.Link, Style: node.Underline, Link: url})
 				i = end
 				continue
 			}
@@ -299,7 +295,7 @@ func parseInline(text string, defaultFG node.Color, colors ColorScheme) []node.N
 		i++
 	}
 	flush(defaultFG, 0)
-	return nodes
+	return spans
 }
 
 func findClose(runes []rune, start int, marker string) int {
@@ -319,7 +315,7 @@ func findClose(runes []rune, start int, marker string) int {
 	return -1
 }
 
-func parseLink(runes []rune, start int) (string, int) {
+func parseLink(runes []rune, start int) (string, string, int) {
 	// [text](url)
 	closeB := -1
 	for i := start + 1; i < len(runes); i++ {
@@ -329,7 +325,7 @@ func parseLink(runes []rune, start int) (string, int) {
 		}
 	}
 	if closeB < 0 || closeB+1 >= len(runes) || runes[closeB+1] != '(' {
-		return "", -1
+		return "", "", -1
 	}
 	closeP := -1
 	for i := closeB + 2; i < len(runes); i++ {
@@ -339,7 +335,7 @@ func parseLink(runes []rune, start int) (string, int) {
 		}
 	}
 	if closeP < 0 {
-		return "", -1
+		return "", "", -1
 	}
-	return string(runes[start+1 : closeB]), closeP + 1
+	return string(runes[start+1 : closeB]), string(runes[closeB+2 : closeP]), closeP + 1
 }
@@ -20,17 +20,17 @@ func TestHeading(t *testing.T) {
 		t.Fatalf("expected 1 node, got %d", len(nodes))
 	}
 	n := nodes[0]
-	if n.Type != node.RowNode {
-		t.Fatalf("expected RowNode, got %v", n.Type)
+	if n.Type != node.TextNode {
+		t.Fatalf("expected TextNode, got %v", n.Type)
 	}
-	if len(n.Children) < 1 {
-		t.Fatal("expected children")
+	if len(n.Props.Spans) < 1 {
+		t.Fatal("expected spans")
 	}
-	if n.Children[0].Props.Style&node.Bold == 0 {
+	if n.Props.Spans[0].Style&node.Bold == 0 {
 		t.Error("heading should be bold")
 	}
-	if n.Children[0].Props.Text != "Hello" {
-		t.Errorf("expected 'Hello', got %q", n.Children[0].Props.Text)
+	if n.Props.Spans[0].Text != "Hello" {
+		t.Errorf("expected 'Hello', got %q", n.Props.Spans[0].Text)
 	}
 }
 
@@ -48,54 +48,57 @@ func TestBold(t *testing.T) {
 	if len(nodes) != 1 {
 		t.Fatal("expected 1 node")
 	}
-	child := nodes[0].Children[0]
-	if child.Props.Style&node.Bold == 0 {
+	span := nodes[0].Props.Spans[0]
+	if span.Style&node.Bold == 0 {
 		t.Error("expected bold")
 	}
-	if child.Props.Text != "bold" {
-		t.Errorf("expected 'bold', got %q", child.Props.Text)
+	if span.Text != "bold" {
+		t.Errorf("expected 'bold', got %q", span.Text)
 	}
 }
 
 func TestItalic(t *testing.T) {
 	nodes := Render("*italic*", 40, 7)
-	child := nodes[0].Children[0]
-	if child.Props.Style&node.Italic == 0 {
+	span := nodes[0].Props.Spans[0]
+	if span.Style&node.Italic == 0 {
 		t.Error("expected italic")
 	}
 }
 
 func TestBoldItalic(t *testing.T) {
 	nodes := Render("***both***", 40, 7)
-	child := nodes[0].Children[0]
-	if child.Props.Style&(node.Bold|node.Italic) != node.Bold|node.Italic {
+	span := nodes[0].Props.Spans[0]
+	if span.Style&(node.Bold|node.Italic) != node.Bold|node.Italic {
 		t.Error("expected bold+italic")
 	}
 }
 
 func TestInlineCode(t *testing.T) {
 	nodes := Render("use `foo` here", 40, 7)
-	row := nodes[0]
-	if len(row.Children) != 3 {
-		t.Fatalf("expected 3 children, got %d", len(row.Children))
+	spans := nodes[0].Props.Spans
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
 	}
-	if row.Children[1].Props.Text != "foo" {
-		t.Errorf("expected 'foo', got %q", row.Children[1].Props.Text)
+	if spans[1].Text != "foo" {
+		t.Errorf("expected 'foo', got %q", spans[1].Text)
 	}
-	if row.Children[1].Props.BG == 0 {
+	if spans[1].BG == 0 {
 		t.Error("expected code background color")
 	}
 }
 
 func TestLink(t *testing.T) {
 	nodes := Render("[click](http://x)", 40, 7)
-	child := nodes[0].Children[0]
-	if child.Props.Text != "click" {
-		t.Errorf("expected 'click', got %q", child.Props.Text)
+	span := nodes[0].Props.Spans[0]
+	if span.Text != "click" {
+		t.Errorf("expected 'click', got %q", span.Text)
 	}
-	if child.Props.Style&node.Underline == 0 {
+	if span.Style&node.Underline == 0 {
 		t.Error("expected underline for link")
 	}
+	if span.Link != "http://x" {
+		t.Errorf("expected link target 'http://x', got %q", span.Link)
+	}
 }
 
 func TestBulletList(t *testing.T) {
@@ -199,22 +202,25 @@ func TestUnclosedMarkers(t *testing.T) {
 	if len(nodes) != 1 {
 		t.Fatal("expected 1 node")
 	}
-	child := nodes[0].Children[0]
-	if child.Props.Text != "**unclosed" {
-		t.Errorf("expected literal '**unclosed', got %q", child.Props.Text)
+	span := nodes[0].Props.Spans[0]
+	if span.Text != "**unclosed" {
+		t.Errorf("expected literal '**unclosed', got %q", span.Text)
 	}
 }
 
 func TestCombinedStyles(t *testing.T) {
 	nodes := Render("hello **bold** and *italic*", 40, 7)
-	row := nodes[0]
-	if len(row.Children) != 4 {
-		t.Fatalf("expected 4 children, got %d", len(row.Children))
+	spans := nodes[0].Props.Spans
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
 	}
-	if row.Children[1].Props.Style&node.Bold == 0 {
+	if spans[1].Style&node.Bold == 0 {
 		t.Error("expected bold")
 	}
-	if row.Children[3].Props.Style&node.Italic == 0 {
+	if spans[3].Style&node.Italic == 0 {
 		t.Error("expected italic")
 	}
+	if nodes[0].Props.Text != "hello bold and italic" {
+		t.Errorf("expected spans to form one paragraph, got %q", nodes[0].Props.Text)
+	}
 }
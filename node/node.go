@@ -13,8 +13,21 @@ const (
 	ListNode
 	PaneNode
 	SpacerNode
+	LayerNode
+	GridNode
+	VirtualListNode
 )
 
+var nodeTypeNames = [...]string{"text", "box", "row", "column", "list", "pane", "spacer", "layer", "grid", "virtual-list"}
+
+// String returns the node type's name, e.g. "column".
+func (t NodeType) String() string {
+	if t < 0 || int(t) >= len(nodeTypeNames) {
+		return "unknown"
+	}
+	return nodeTypeNames[t]
+}
+
 // Color represents an ANSI 256-color value. 0 means default/unset.
 type Color uint8
 
@@ -37,22 +50,212 @@ const (
 	BorderSingle
 	BorderDouble
 	BorderRounded
+	BorderThick
+	BorderASCII
+	BorderDashed
+	BorderCustom // drawn with Props.BorderRunes
+)
+
+// BorderRunes is the set of runes a border is drawn with.
+type BorderRunes struct {
+	TopLeft, TopRight, BottomLeft, BottomRight rune
+	Horizontal, Vertical                       rune
+}
+
+// Runes returns the rune set for a built-in border style. BorderNone and
+// BorderCustom return the zero set.
+func (b BorderStyle) Runes() BorderRunes {
+	switch b {
+	case BorderSingle:
+		return BorderRunes{'┌', '┐', '└', '┘', '─', '│'}
+	case BorderDouble:
+		return BorderRunes{'╔', '╗', '╚', '╝', '═', '║'}
+	case BorderRounded:
+		return BorderRunes{'╭', '╮', '╰', '╯', '─', '│'}
+	case BorderThick:
+		return BorderRunes{'┏', '┓', '┗', '┛', '━', '┃'}
+	case BorderASCII:
+		return BorderRunes{'+', '+', '+', '+', '-', '|'}
+	case BorderDashed:
+		return BorderRunes{'┌', '┐', '└', '┘', '╌', '╎'}
+	}
+	return BorderRunes{}
+}
+
+// Sides selects the edges of a Box that have a border. Zero means all four.
+type Sides uint8
+
+const (
+	SideTop Sides = 1 << iota
+	SideRight
+	SideBottom
+	SideLeft
+
+	SidesAll = SideTop | SideRight | SideBottom | SideLeft
+)
+
+// Has reports whether s includes side. Zero Sides includes every side.
+func (s Sides) Has(side Sides) bool {
+	return s == 0 || s&side != 0
+}
+
+// Justify distributes free space along a container's main axis
+// (horizontal for Row, vertical for Column).
+type Justify int
+
+const (
+	JustifyStart Justify = iota
+	JustifyCenter
+	JustifyEnd
+	JustifySpaceBetween
+	JustifySpaceAround
+	JustifySpaceEvenly
+)
+
+// Align positions children along a container's cross axis
+// (vertical for Row, horizontal for Column).
+type Align int
+
+const (
+	AlignStretch Align = iota // fill the cross axis (default)
+	AlignStart
+	AlignCenter
+	AlignEnd
+)
+
+// TextAlign controls horizontal alignment of text lines within their rect.
+type TextAlign int
+
+const (
+	TextAlignLeft TextAlign = iota
+	TextAlignCenter
+	TextAlignRight
+)
+
+// Overflow controls how a Text node handles lines wider than its rect.
+type Overflow int
+
+const (
+	OverflowWrap           Overflow = iota // word-wrap, breaking words longer than the line (default)
+	OverflowClip                           // never wrap; lines extend past the rect and are clipped
+	OverflowWrapChar                       // wrap at any character, ignoring word boundaries
+	OverflowEllipsis                       // one line per paragraph, "abc…" when too long
+	OverflowEllipsisStart                  // one line per paragraph, "…xyz" when too long
+	OverflowEllipsisMiddle                 // one line per paragraph, "ab…yz" when too long (paths, hashes)
+)
+
+// Placement positions a layer relative to its anchor rect (the screen when
+// no anchor key is set).
+type Placement int
+
+const (
+	PlaceCenter      Placement = iota // centered over the anchor
+	PlaceTopLeft                      // inside the anchor's top-left corner
+	PlaceTopRight                     // inside the anchor's top-right corner
+	PlaceBottomLeft                   // inside the anchor's bottom-left corner
+	PlaceBottomRight                  // inside the anchor's bottom-right corner
+	PlaceBelow                        // just below the anchor, left edges aligned
+	PlaceAbove                        // just above the anchor, left edges aligned
+)
+
+// TrackKind selects how a grid row or column is sized.
+type TrackKind int
+
+const (
+	TrackFixed TrackKind = iota // exactly Size cells
+	TrackFr                     // share of the leftover space, weighted by Size
+	TrackAuto                   // sized to the largest content in the track
 )
 
+// Track defines the sizing of one grid row or column.
+type Track struct {
+	Kind TrackKind
+	Size int
+}
+
+// Fixed returns a track exactly n cells wide (or tall).
+func Fixed(n int) Track {
+	return Track{Kind: TrackFixed, Size: n}
+}
+
+// Fr returns a flexible track that takes a weighted share of leftover space.
+func Fr(weight int) Track {
+	return Track{Kind: TrackFr, Size: weight}
+}
+
+// Auto returns a track sized to its content.
+func Auto() Track {
+	return Track{Kind: TrackAuto}
+}
+
+// Span is a styled run of text inside a rich text node. Zero colors fall
+// back to the node's FG/BG; Style is combined with the node's Style.
+type Span struct {
+	Text  string
+	FG    Color
+	BG    Color
+	Style StyleFlags
+	Link  string // hyperlink target, emitted as an OSC 8 link
+}
+
 // Props holds configurable properties for a node.
 type Props struct {
 	Text       string
 	Width      int // 0 = auto
 	Height     int // 0 = auto
 	FlexWeight int // 0 = no flex, >0 = relative weight
+	FlexShrink int  // Row child: relative share of overflow to give up, 0 = never shrink
+	FlexBasis  int  // Row child: width before growing or shrinking, 0 = intrinsic (0 for flex children)
+	FlexWrap   bool // Row: flow children onto further lines instead of overflowing
 	Border     BorderStyle
+	BorderRunes BorderRunes // Box: runes for BorderCustom
+	BorderFG    Color       // Box: border color, 0 = FG
+	BorderBG    Color       // Box: border background, 0 = BG
+	BorderAttr  StyleFlags  // Box: border style flags, 0 = Style
+	Sides       Sides       // Box: edges with a border, 0 = all
+	Title       string      // Box: label embedded in the top border
+	TitleAlign  TextAlign   // Box: position of the title
+	Footer      string      // Box: label embedded in the bottom border
+	FooterAlign TextAlign   // Box: position of the footer
 	Focusable  bool
 	Key        string
+	Version    int // keyed nodes: bump when the subtree changes; 0 = never reuse its layout
 	FG           Color
 	BG           Color
 	Style        StyleFlags
 	ScrollOffset   int  // vertical scroll offset for Column/List/Pane
 	ScrollToBottom bool // auto-scroll so bottom content is visible
+	ScrollX        int  // horizontal scroll offset for Row/Column/Pane/Text
+	HScroll        bool // lay out children at natural width so ScrollX can pan them
+	Scrollbar      bool // reserve a one-column gutter for a vertical scrollbar
+	Overflow       Overflow // Text: how lines wider than the rect are handled
+	Spans          []Span   // Text: styled runs; Text holds their concatenation
+
+	Justify   Justify   // main-axis distribution for Row/Column
+	Align     Align     // cross-axis alignment for Row/Column children
+	TextAlign TextAlign // horizontal alignment of Text lines
+
+	Anchor    string    // Layer: key of the node to position against ("" = screen)
+	Placement Placement // Layer: position relative to the anchor
+	OffsetX   int       // Layer: horizontal nudge after placement
+	OffsetY   int       // Layer: vertical nudge after placement
+	ZIndex    int       // Layer: paint order, higher paints later
+	Backdrop  bool      // Layer: dim everything painted beneath it
+	Shadow    bool      // Layer: draw a drop shadow to the bottom-right
+
+	GridColumns []Track // Grid: column tracks
+	GridRows    []Track // Grid: row tracks; rows beyond these are Auto
+	RowGap      int     // Grid: empty rows between tracks; wrapping Row: between lines
+	ColGap      int     // Grid: empty columns between tracks; Row: between children
+	GridRow     int     // Grid child: 1-based row, 0 = auto-placed
+	GridCol     int     // Grid child: 1-based column, 0 = auto-placed
+	RowSpan     int     // Grid child: rows spanned (0 or 1 = one row)
+	ColSpan     int     // Grid child: columns spanned (0 or 1 = one column)
+
+	Items    int              // VirtualList: number of rows
+	Row      func(i int) Node // VirtualList: builds row i on demand
+	Overscan int              // VirtualList: extra rows built beyond each edge of the viewport
+	Selected int              // VirtualList: row kept in view, -1 for none
 }
 
 // Node represents a virtual UI element in the component tree.
@@ -72,6 +275,16 @@ func TextStyled(s string, fg, bg Color, style StyleFlags) Node {
 	return Node{Type: TextNode, Props: Props{Text: s, FG: fg, BG: bg, Style: style}}
 }
 
+// RichText returns a Text node made of styled spans. The spans wrap together
+// as one paragraph, each keeping its own style across line breaks.
+func RichText(spans ...Span) Node {
+	var sb strings.Builder
+	for _, sp := range spans {
+		sb.WriteString(sp.Text)
+	}
+	return Node{Type: TextNode, Props: Props{Text: sb.String(), Spans: spans}}
+}
+
 func Row(children ...Node) Node {
 	return Node{Type: RowNode, Children: children}
 }
@@ -96,18 +309,75 @@ func Spacer() Node {
 	return Node{Type: SpacerNode, Props: Props{FlexWeight: 1}}
 }
 
+// Layer floats child above the main tree. It takes no space in the flow
+// layout; instead it is positioned against the rect of the node whose key is
+// anchor, or against the whole screen when anchor is "". Layers are painted
+// after the main tree in ZIndex order.
+func Layer(anchor string, placement Placement, child Node) Node {
+	return Node{Type: LayerNode, Props: Props{Anchor: anchor, Placement: placement}, Children: []Node{child}}
+}
+
+// Modal is a screen-centered Layer with a dimmed backdrop.
+func Modal(child Node) Node {
+	return Layer("", PlaceCenter, child).WithBackdrop()
+}
+
+// Grid lays children out in aligned rows and columns. Children fill cells in
+// row-major order unless placed explicitly with WithGridCell; rows are added
+// as needed and are content-sized unless WithRows says otherwise.
+func Grid(columns []Track, children ...Node) Node {
+	return Node{Type: GridNode, Props: Props{GridColumns: columns}, Children: children}
+}
+
+// VirtualList creates a list of count rows built on demand by row, so only
+// the rows in view are ever rendered or laid out. ScrollOffset is the index
+// of the first visible row. Give it a flex weight or an explicit height.
+func VirtualList(count int, row func(i int) Node) Node {
+	return Node{Type: VirtualListNode, Props: Props{Items: count, Row: row, Selected: -1}}
+}
+
 // WithKey sets the key on a node and returns it.
 func (n Node) WithKey(key string) Node {
 	n.Props.Key = key
 	return n
 }
 
+// WithVersion sets the version of a keyed subtree and returns the node. The
+// layout engine reuses last frame's layout of the subtree while its key,
+// version and available space are unchanged, so bump the version whenever
+// anything inside it changes.
+func (n Node) WithVersion(v int) Node {
+	n.Props.Version = v
+	return n
+}
+
 // WithFlex sets the flex weight and returns the node.
 func (n Node) WithFlex(weight int) Node {
 	n.Props.FlexWeight = weight
 	return n
 }
 
+// WithFlexShrink lets a Row child give up width when the row overflows, in
+// proportion to factor times its basis.
+func (n Node) WithFlexShrink(factor int) Node {
+	n.Props.FlexShrink = factor
+	return n
+}
+
+// WithFlexBasis sets the width a Row child starts from before free space is
+// shared out or overflow taken back.
+func (n Node) WithFlexBasis(w int) Node {
+	n.Props.FlexBasis = w
+	return n
+}
+
+// WithFlexWrap makes a Row flow its children onto further lines when they do
+// not fit, separated by ColGap within a line and RowGap between lines.
+func (n Node) WithFlexWrap() Node {
+	n.Props.FlexWrap = true
+	return n
+}
+
 // WithSize sets explicit width/height and returns the node.
 func (n Node) WithSize(w, h int) Node {
 	n.Props.Width = w
@@ -132,6 +402,165 @@ func (n Node) WithScrollToBottom() Node {
 	return n
 }
 
+// WithScrollbar makes a Column/List/Pane scrollable and reserves its
+// rightmost column for a scrollbar showing the visible part of the content.
+func (n Node) WithScrollbar() Node {
+	n.Props.Scrollbar = true
+	return n
+}
+
+// WithTitle embeds a label in a Box's top border.
+func (n Node) WithTitle(title string, align TextAlign) Node {
+	n.Props.Title = title
+	n.Props.TitleAlign = align
+	return n
+}
+
+// WithFooter embeds a label in a Box's bottom border.
+func (n Node) WithFooter(footer string, align TextAlign) Node {
+	n.Props.Footer = footer
+	n.Props.FooterAlign = align
+	return n
+}
+
+// WithBorderColor colors a Box's border and labels separately from its content.
+func (n Node) WithBorderColor(fg, bg Color) Node {
+	n.Props.BorderFG = fg
+	n.Props.BorderBG = bg
+	return n
+}
+
+// WithBorderAttr sets the style flags of a Box's border and labels.
+func (n Node) WithBorderAttr(style StyleFlags) Node {
+	n.Props.BorderAttr = style
+	return n
+}
+
+// WithSides limits a Box's border to the given edges. Edges without a
+// border take no space.
+func (n Node) WithSides(s Sides) Node {
+	n.Props.Sides = s
+	return n
+}
+
+// WithBorderRunes draws a Box's border with a custom rune set.
+func (n Node) WithBorderRunes(r BorderRunes) Node {
+	n.Props.Border = BorderCustom
+	n.Props.BorderRunes = r
+	return n
+}
+
+// WithSelected sets the VirtualList row that is always scrolled into view.
+func (n Node) WithSelected(i int) Node {
+	n.Props.Selected = i
+	return n
+}
+
+// WithOverscan sets how many rows a VirtualList builds beyond each edge of
+// the viewport.
+func (n Node) WithOverscan(rows int) Node {
+	n.Props.Overscan = rows
+	return n
+}
+
+// WithScrollX enables horizontal scrolling and sets the horizontal offset.
+// Children keep their natural width instead of being squeezed into the rect,
+// and the visible window starts offset cells from the left.
+func (n Node) WithScrollX(offset int) Node {
+	n.Props.ScrollX = offset
+	n.Props.HScroll = true
+	return n
+}
+
+// WithScroll sets independent horizontal and vertical scroll offsets.
+func (n Node) WithScroll(x, y int) Node {
+	return n.WithScrollX(x).WithScrollOffset(y)
+}
+
+// WithOverflow sets how a Text node handles lines wider than its rect.
+func (n Node) WithOverflow(o Overflow) Node {
+	n.Props.Overflow = o
+	return n
+}
+
+// WithNoWrap stops a Text node from wrapping; long lines are clipped at the
+// rect edge and can be panned with WithScrollX.
+func (n Node) WithNoWrap() Node {
+	n.Props.Overflow = OverflowClip
+	return n
+}
+
+// WithJustify sets how a Row or Column distributes free main-axis space.
+func (n Node) WithJustify(j Justify) Node {
+	n.Props.Justify = j
+	return n
+}
+
+// WithAlign sets how a Row or Column positions children on the cross axis.
+func (n Node) WithAlign(a Align) Node {
+	n.Props.Align = a
+	return n
+}
+
+// WithTextAlign sets the horizontal alignment of a Text node's lines.
+func (n Node) WithTextAlign(a TextAlign) Node {
+	n.Props.TextAlign = a
+	return n
+}
+
+// WithOffset nudges a layer by (dx, dy) cells after placement.
+func (n Node) WithOffset(dx, dy int) Node {
+	n.Props.OffsetX = dx
+	n.Props.OffsetY = dy
+	return n
+}
+
+// WithZIndex sets the paint order of a layer. Higher values paint on top.
+func (n Node) WithZIndex(z int) Node {
+	n.Props.ZIndex = z
+	return n
+}
+
+// WithBackdrop dims everything painted beneath a layer.
+func (n Node) WithBackdrop() Node {
+	n.Props.Backdrop = true
+	return n
+}
+
+// WithShadow draws a drop shadow to the bottom-right of a layer.
+func (n Node) WithShadow() Node {
+	n.Props.Shadow = true
+	return n
+}
+
+// WithRows sets the row tracks of a grid.
+func (n Node) WithRows(tracks ...Track) Node {
+	n.Props.GridRows = tracks
+	return n
+}
+
+// WithGap sets the spacing between grid rows and columns, or between a
+// Row's lines and children.
+func (n Node) WithGap(row, col int) Node {
+	n.Props.RowGap = row
+	n.Props.ColGap = col
+	return n
+}
+
+// WithGridCell places a grid child at the given 1-based row and column.
+func (n Node) WithGridCell(row, col int) Node {
+	n.Props.GridRow = row
+	n.Props.GridCol = col
+	return n
+}
+
+// WithSpan makes a grid child span several rows and columns.
+func (n Node) WithSpan(rows, cols int) Node {
+	n.Props.RowSpan = rows
+	n.Props.ColSpan = cols
+	return n
+}
+
 // Bar creates a full-width text node with background color fill.
 // Use in a Row; the FlexWeight=1 causes it to stretch to fill available width.
 func Bar(text string, fg, bg Color, style StyleFlags) Node {
@@ -154,3 +154,28 @@ func TestParagraphStyled(t *testing.T) {
 		t.Fatal("expected FG 5")
 	}
 }
+
+func TestRichText(t *testing.T) {
+	n := RichText(Span{Text: "a "}, Span{Text: "b", Style: Bold})
+	if n.Type != TextNode {
+		t.Fatal("expected TextNode")
+	}
+	if n.Props.Text != "a b" {
+		t.Fatalf("expected concatenated text 'a b', got %q", n.Props.Text)
+	}
+	if len(n.Props.Spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(n.Props.Spans))
+	}
+}
+
+func TestNodeTypeString(t *testing.T) {
+	if s := ColumnNode.String(); s != "column" {
+		t.Errorf("expected column, got %q", s)
+	}
+	if s := VirtualListNode.String(); s != "virtual-list" {
+		t.Errorf("expected virtual-list, got %q", s)
+	}
+	if s := NodeType(99).String(); s != "unknown" {
+		t.Errorf("expected unknown, got %q", s)
+	}
+}
@@ -0,0 +1,32 @@
+// Package ssesub adapts an sse.Client to an app.Subscription, keeping the
+// sse package free of the app runtime.
+package ssesub
+
+import (
+	"context"
+
+	"github.com/stukennedy/tooey/app"
+	"github.com/stukennedy/tooey/sse"
+)
+
+// Subscription returns an app.Subscription that streams c's events as
+// messages made by fn; events for which fn returns nil are dropped. It is
+// keyed by URL, so declaring a client for another URL cancels the old
+// stream and starts the new one.
+func Subscription(c *sse.Client, fn func(sse.Event) app.Msg) app.Subscription {
+	return app.Subscription{
+		Key: "sse:" + c.URL,
+		Run: func(ctx context.Context, send func(app.Msg)) app.Msg {
+			events, err := c.Connect(ctx)
+			if err != nil {
+				return nil
+			}
+			for ev := range events {
+				if m := fn(ev); m != nil {
+					send(m)
+				}
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,55 @@
+package ssesub
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stukennedy/tooey/app"
+	"github.com/stukennedy/tooey/sse"
+)
+
+func TestSubscription(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event:state\ndata:1\n\nevent:ping\ndata:2\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	client := &sse.Client{URL: srv.URL, RetryDelay: 100 * time.Millisecond}
+	sub := Subscription(client, func(ev sse.Event) app.Msg {
+		if ev.Type != "state" {
+			return nil
+		}
+		return string(ev.Data)
+	})
+	if sub.Key != "sse:"+srv.URL {
+		t.Fatalf("unexpected key %q", sub.Key)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	msgs := make(chan app.Msg, 4)
+	done := make(chan struct{})
+	go func() {
+		sub.Run(ctx, func(m app.Msg) { msgs <- m })
+		close(done)
+	}()
+
+	if m := <-msgs; m != "1" {
+		t.Fatalf("expected state message, got %v", m)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscription did not stop when cancelled")
+	}
+	if len(msgs) != 0 {
+		t.Fatalf("expected filtered events to be dropped, got %v", <-msgs)
+	}
+}